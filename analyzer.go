@@ -1,10 +1,30 @@
 package cfg
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// stdout serializes the output of the Print functions, so the reports of
+// concurrent calls don't interleave
+var stdout sync.Mutex
+
 // analyzer contains base data for analyzing all supported types of config files.
 //
 // The working file is considered to be the current local or active config file
@@ -13,42 +33,366 @@ import (
 // The master file is considered to be the 'compare to' file which could either
 // be a local example file or an active remote config file on a server.
 type analyzer struct {
-	working   []byte
-	master    []byte
-	bash      *bash
-	missing   []string
-	different []string
+	config       Config
+	ctx          context.Context
+	log          *slog.Logger
+	started      time.Time
+	working      []byte
+	master       []byte
+	ignore       []*regexp.Regexp
+	ignoreKeys   *keyFilter
+	workingReal  string
+	masterReal   string
+	bash         *bash
+	sftp         *sftpFetcher
+	http         *httpFetcher
+	missing      []string
+	extra        []string
+	forbidden    []string
+	dangling     []string
+	orderChanged []string
+	malformed    []string
+	usingDefault []string
+	different    []DiffEntry
+	comments     []DiffEntry
+	elements     map[string]ElementDiff
+	defaults     map[string]bool
+	sources      map[string]string
+	required     map[string]bool
+	typeWarnings []string
+	mismatches   []TypeMismatch
+	optional     []string
+	shapeChanged []string
+	cache        *masterCache
 }
 
-// newAnalyzer returns a new analyzer
+// newAnalyzer returns a new analyzer loaded with the working and master files
 func newAnalyzer(c Config) (*analyzer, error) {
-	a := analyzer{}
+	return newAnalyzerContext(context.Background(), c)
+}
+
+// newAnalyzerContext returns a new analyzer loaded with the working and
+// master files, fetching remote files with ctx
+func newAnalyzerContext(ctx context.Context, c Config) (*analyzer, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	c, err := c.latestMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := initAnalyzer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	a.ctx = ctx
+
+	if err := a.remote(); err != nil {
+		return nil, err
+	}
+
+	if err := a.read(c.WorkingPath, c.MasterPath); err != nil {
+		return nil, err
+	}
+
+	if !c.AllowSamePath && a.samePath() {
+		return nil, fmt.Errorf("working and master point to the same file %s", a.workingReal)
+	}
+
+	return a, nil
+}
+
+// samePath determines if the working and master files were both read from
+// the same local file, which would always compare as identical
+func (a *analyzer) samePath() bool {
+	if a.workingReal == "" || a.masterReal == "" {
+		return false
+	}
+
+	working, err := os.Stat(a.workingReal)
+	if err != nil {
+		return false
+	}
 
+	master, err := os.Stat(a.masterReal)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(working, master)
+}
+
+// remote prepares the analyzer to fetch a master file that doesn't live on
+// the local filesystem
+func (a *analyzer) remote() error {
 	// attempt to connect if a hostAlias is provided
-	if len(c.HostAlias) > 0 {
-		if err := a.connect(c.HostAlias); err != nil {
-			return nil, err
+	if len(a.config.HostAlias) > 0 {
+		if err := a.connect(a.config.HostAlias); err != nil {
+			return err
 		}
 	}
 
-	if err := a.read(c.WorkingPath, c.MasterPath); err != nil {
+	// read the master over a native ssh connection if a host is provided
+	if a.config.SSHHost != "" {
+		var err error
+		if a.sftp, err = newSftpFetcher(a.config); err != nil {
+			return err
+		}
+	}
+
+	// fetch the master over http(s) if a url is provided
+	if isUrl(a.config.MasterPath) {
+		var proxy *url.URL
+		if a.config.Proxy != "" {
+			var err error
+			if proxy, err = url.Parse(a.config.Proxy); err != nil {
+				return fmt.Errorf("invalid proxy %s. %s", a.config.Proxy, err)
+			}
+		}
+
+		tlsConfig, err := a.config.tlsConfig()
+		if err != nil {
+			return err
+		}
+
+		a.http = newHttpFetcher(a.config.HTTPTimeout, a.config.MaxBytes, proxy, tlsConfig, a.config.HTTPHeaders)
+	}
+
+	return nil
+}
+
+// initAnalyzer returns a new analyzer holding the config, before any files
+// are read
+func initAnalyzer(c Config) (*analyzer, error) {
+	a := analyzer{config: c, ctx: context.Background(), log: c.logger(), started: time.Now()}
+
+	for _, pattern := range c.IgnoreValuePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore value pattern %s. %s", pattern, err)
+		}
+		a.ignore = append(a.ignore, re)
+	}
+
+	var err error
+	if a.ignoreKeys, err = newKeyFilter(c.Ignore, c.keySeparator()); err != nil {
 		return nil, err
 	}
 
 	return &a, nil
 }
 
+// Scan will scan two configuration files of the same format, determined by
+// Config.Format or the working file's extension, returning a Result
+func Scan(c Config) (*Result, error) {
+	return ScanContext(context.Background(), c)
+}
+
+// ScanContext is Scan, abandoning the fetch of a remote master over ssh or
+// http(s) if ctx is done first
+func ScanContext(ctx context.Context, c Config) (*Result, error) {
+	base, err := newAnalyzerContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := base.scan(c.format())
+	if err != nil {
+		return nil, err
+	}
+
+	return r, base.config.strict(r)
+}
+
+// ScanWorkings scans several working files against the same master file,
+// e.g. the configs of each service sharing one template, returning a Result
+// per working path. the master is only read once. a working file that can't
+// be read or parsed doesn't stop the others being scanned, its error is held
+// by its Result and returned in ScanErrors along with the other results,
+// unless Config.FailFast is set
+func ScanWorkings(workingPaths []string, c Config) (map[string]*Result, error) {
+	return ScanWorkingsContext(context.Background(), workingPaths, c)
+}
+
+// ScanWorkingsContext is ScanWorkings, abandoning the fetch of a remote
+// master if ctx is done first. no further working files are scanned once
+// ctx is done, and its error is returned
+func ScanWorkingsContext(ctx context.Context, workingPaths []string, c Config) (map[string]*Result, error) {
+	// the config is validated as it would be for the first working file
+	v := c
+	if len(workingPaths) > 0 {
+		v.WorkingPath = workingPaths[0]
+	}
+
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	c, err := c.latestMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := initAnalyzer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	master.ctx = ctx
+
+	if err := master.remote(); err != nil {
+		return nil, err
+	}
+
+	if err := master.loadMaster(c.MasterPath); err != nil {
+		return nil, err
+	}
+
+	// each working file is compared to the same master, so it's only parsed once
+	master.cache = newMasterCache()
+
+	results, errs := map[string]*Result{}, ScanErrors{}
+
+	for _, path := range workingPaths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		a := *master
+		a.config.WorkingPath = path
+		a.started = time.Now()
+
+		err := a.readWorking(path)
+		if err == nil {
+			err = a.checkConflicts(path, a.config.MasterPath)
+		}
+
+		if err == nil && !c.AllowSamePath && a.samePath() {
+			err = fmt.Errorf("working and master point to the same file %s", a.workingReal)
+		}
+
+		if err != nil {
+			if err := a.fail(results, errs, path, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		result, err := a.scan(a.config.format())
+		if err != nil {
+			if err := a.fail(results, errs, path, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		results[path] = result
+
+		if err := a.config.strict(result); err != nil {
+			if a.config.FailFast {
+				return nil, err
+			}
+			errs[path] = err
+		}
+	}
+
+	return results, errs.err()
+}
+
+// fail records the error of one file of a multi-file scan under name, in
+// its Result and errs, returning it instead if Config.FailFast is set
+func (a analyzer) fail(results map[string]*Result, errs ScanErrors, name string, err error) error {
+	if a.config.FailFast {
+		return err
+	}
+
+	a.log.Warn("could not scan file", "path", a.config.WorkingPath, "error", err)
+
+	results[name] = &Result{
+		WorkingPath: a.config.WorkingPath,
+		MasterPath:  a.config.MasterPath,
+		Error:       err.Error(),
+		ScannedAt:   a.started,
+		Duration:    time.Since(a.started),
+	}
+	errs[name] = err
+
+	return nil
+}
+
 // ScanJson will scan two .json configuration files returning a slice
-// of keys that exist in the master file and are missing in the working file
+// of keys that exist in the master file and are missing in the working file.
+// nested keys are returned as dotted paths, e.g. database.replica.host, and
+// the keys of objects within arrays by their index, e.g. servers.0.port.
+// the files are streamed rather than parsed in full, unless an option changes
+// their keys, e.g. Config.RootPath or Config.Ignore
 func ScanJson(c Config) ([]string, error) {
-	analyzer, err := newJsonAnalyzer(c)
+	return ScanJsonContext(context.Background(), c)
+}
+
+// ScanJsonContext is ScanJson, abandoning the fetch of a remote master over
+// ssh or http(s) if ctx is done first
+func ScanJsonContext(ctx context.Context, c Config) ([]string, error) {
+	base, err := newAnalyzerContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// when only the keys matter the files are streamed. a file that can't be
+	// is left to be parsed in full, which reports why
+	if base.config.streamsKeys() {
+		if missing, err := base.streamedMissing(); err == nil {
+			base.missing = missing
+			return missing, base.config.strict(base.result())
+		}
+	}
+
+	analyzer, err := loadJsonAnalyzer(base)
 	if err != nil {
 		return nil, err
 	}
 
 	analyzer.scan()
 
-	return analyzer.missing, nil
+	return analyzer.missing, analyzer.config.strict(analyzer.result())
+}
+
+// streamsKeys determines if the missing keys of nested files can be found by
+// streaming them, no option changing their keys or how they're compared
+func (c Config) streamsKeys() bool {
+	return c.format().nested() && !c.flattened(c.WorkingPath) && !c.flattened(c.MasterPath) &&
+		!c.ResolveRefs && c.RootPath == "" && c.KeyTransform == nil && c.WorkingTransform == "" &&
+		c.MasterTransform == "" && len(c.Ignore) == 0 && len(c.OptionalSections) == 0 &&
+		len(c.ArrayModes) == 0 && c.DefaultsPath == "" && c.PathStyle != PathJSONPointer
+}
+
+// streamedMissing streams the working and master files, returning the keys
+// missing from the working file
+func (a *analyzer) streamedMissing() ([]string, error) {
+	sep := a.config.keySeparator()
+
+	working, err := streamShapes(a.working, sep)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := streamShapes(a.master, sep)
+	if err != nil {
+		return nil, err
+	}
+
+	return streamedMissing(working, master), nil
+}
+
+// ScanJsonResult will scan two .json configuration files returning a Result
+// holding every missing, extra and different key, for callers doing their
+// own reporting
+func ScanJsonResult(c Config) (*Result, error) {
+	c.Format = FormatJson
+	return Scan(c)
 }
 
 // PrintJson uses ScanJson to retrieve a slice of missing keys and will then
@@ -59,36 +403,90 @@ func PrintJson(c Config) error {
 		return err
 	}
 
+	// MasterGlob is resolved to a MasterPath by the analyzer
+	c = analyzer.config
+
 	analyzer.scan()
 
-	if len(analyzer.missing) > 0 {
-		fmt.Printf("(!) found missing keys in %s: %+v\n", c.WorkingPath, analyzer.missing)
-		return nil
+	strict := c.strict(analyzer.result())
+
+	stdout.Lock()
+	defer stdout.Unlock()
+
+	if c.OutputFormat == OutputJson || c.OutputFormat == OutputYaml {
+		if err := WriteResult(os.Stdout, analyzer.result(), c.OutputFormat); err != nil {
+			return err
+		}
+		return strict
 	}
 
-	equal, err := analyzer.equality()
-	if err != nil {
-		return err
+	printForbidden(c, analyzer.result())
+	printOrderChanged(c, analyzer.result())
+	printTypeWarnings(c, analyzer.result())
+	printOptionalSections(c, analyzer.result())
+	printShapeChanged(c, analyzer.result())
+	printTypeMismatches(c, analyzer.result())
+
+	printUsingDefault(c, analyzer.result())
+
+	// the working file's keys lead from its perspective, otherwise the
+	// master's keys it's missing do
+	if c.Perspective == PerspectiveWorking {
+		printExtra(c, analyzer.result())
 	}
 
-	if !equal {
-		fmt.Printf("(!) %s and %s are different. Ignore if this is intentional\n", c.WorkingPath, c.MasterPath)
+	switch {
+	case len(analyzer.missing) > 0 && printMissing(c, analyzer.result()):
+	case len(analyzer.different) > 0:
+		printDifferent(c, analyzer.result())
+	default:
+		equal, err := analyzer.equality()
+		if err != nil {
+			return err
+		}
+
+		if !equal {
+			fmt.Printf("(!) %s and %s are different. Ignore if this is intentional\n", c.WorkingPath, c.MasterPath)
+		}
 	}
 
-	return nil
+	if c.Perspective != PerspectiveWorking {
+		printExtra(c, analyzer.result())
+	}
+
+	return strict
 }
 
 // ScanEnv will scan two .env configuration files returning a slice
 // of keys that exist in the master file and are missing in the working file
 func ScanEnv(c Config) ([]string, error) {
-	analyzer, err := newEnvAnalyzer(c)
+	return ScanEnvContext(context.Background(), c)
+}
+
+// ScanEnvContext is ScanEnv, abandoning the fetch of a remote master over ssh
+// or http(s) if ctx is done first
+func ScanEnvContext(ctx context.Context, c Config) ([]string, error) {
+	base, err := newAnalyzerContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer, err := loadEnvAnalyzer(base)
 	if err != nil {
 		return nil, err
 	}
 
 	analyzer.scan()
 
-	return analyzer.missing, nil
+	return analyzer.missing, analyzer.config.strict(analyzer.result())
+}
+
+// ScanEnvResult will scan two .env configuration files returning a Result
+// holding every missing, extra and different key, for callers doing their
+// own reporting
+func ScanEnvResult(c Config) (*Result, error) {
+	c.Format = FormatEnv
+	return Scan(c)
 }
 
 // PrintEnv uses ScanEnv to retrieve a slice of missing keys and will then
@@ -99,61 +497,834 @@ func PrintEnv(c Config) error {
 		return err
 	}
 
+	// MasterGlob is resolved to a MasterPath by the analyzer
+	c = analyzer.config
+
 	analyzer.scan()
 
-	if len(analyzer.missing) > 0 {
-		fmt.Printf("(!) found missing keys in %s: %+v\n", c.WorkingPath, analyzer.missing)
-		return nil
+	strict := c.strict(analyzer.result())
+
+	stdout.Lock()
+	defer stdout.Unlock()
+
+	if c.OutputFormat == OutputJson || c.OutputFormat == OutputYaml {
+		if err := WriteResult(os.Stdout, analyzer.result(), c.OutputFormat); err != nil {
+			return err
+		}
+		return strict
+	}
+
+	printForbidden(c, analyzer.result())
+	printMalformed(c, analyzer.result())
+	printDangling(c, analyzer.result())
+	printOrderChanged(c, analyzer.result())
+	printCommentChanged(c, analyzer.result())
+
+	printUsingDefault(c, analyzer.result())
+
+	// the working file's keys lead from its perspective, otherwise the
+	// master's keys it's missing do
+	if c.Perspective == PerspectiveWorking {
+		printExtra(c, analyzer.result())
 	}
 
-	if len(analyzer.different) > 0 {
-		fmt.Printf("(!) %s and %s are different. Ignore if this is intentional\n", c.WorkingPath, c.MasterPath)
-		fmt.Printf("%+v\n", analyzer.different)
-		return nil
+	switch {
+	case len(analyzer.missing) > 0 && printMissing(c, analyzer.result()):
+	case len(analyzer.different) > 0:
+		printDifferent(c, analyzer.result())
 	}
 
-	return nil
+	if c.Perspective != PerspectiveWorking {
+		printExtra(c, analyzer.result())
+	}
+
+	return strict
+}
+
+// printForbidden prints the forbidden keys of a Result, if any
+func printForbidden(c Config, r *Result) {
+	if len(r.Forbidden) > 0 {
+		fmt.Printf("(!) found forbidden keys in %s: %+v\n", label(c.WorkingPath, r.WorkingRealPath), r.Forbidden)
+	}
+}
+
+// printUsingDefault prints the keys of a Result that fall back to their
+// defaults, if any
+func printUsingDefault(c Config, r *Result) {
+	if len(r.UsingDefault) > 0 {
+		fmt.Printf("(i) keys missing in %s are using their defaults from %s: %+v\n",
+			label(c.WorkingPath, r.WorkingRealPath), c.DefaultsPath, r.UsingDefault)
+	}
+}
+
+// printTypeWarnings prints the values of a Result that were only equal once
+// coerced, if any
+func printTypeWarnings(c Config, r *Result) {
+	if len(r.TypeWarnings) > 0 {
+		fmt.Printf("(i) values of different types compared equal: %+v\n", r.TypeWarnings)
+	}
+}
+
+// printShapeChanged prints the keys of a Result whose values changed between
+// a scalar and an array, if any
+func printShapeChanged(c Config, r *Result) {
+	if len(r.ShapeChanged) > 0 {
+		fmt.Printf("(!) values in %s changed between a scalar and an array: %+v\n",
+			label(c.WorkingPath, r.WorkingRealPath), r.ShapeChanged)
+	}
+}
+
+// printCommentChanged prints the keys of a Result whose leading comments
+// differ, if any
+func printCommentChanged(c Config, r *Result) {
+	if len(r.CommentChanged) > 0 {
+		fmt.Printf("(i) comments in %s differ from %s: %+v\n",
+			label(c.WorkingPath, r.WorkingRealPath), label(c.MasterPath, r.MasterRealPath), r.CommentChanged)
+	}
+}
+
+// printTypeMismatches prints the keys of a Result whose values changed type,
+// if any
+func printTypeMismatches(c Config, r *Result) {
+	if len(r.TypeMismatches) > 0 {
+		fmt.Printf("(!) values in %s changed type: %+v\n",
+			label(c.WorkingPath, r.WorkingRealPath), r.TypeMismatches)
+	}
+}
+
+// printOptionalSections prints the optional sections of a Result that the
+// working file doesn't configure, if any
+func printOptionalSections(c Config, r *Result) {
+	if len(r.OptionalSections) > 0 {
+		fmt.Printf("(i) optional sections not configured in %s: %+v\n",
+			label(c.WorkingPath, r.WorkingRealPath), r.OptionalSections)
+	}
+}
+
+// printMalformed prints the malformed lines of a Result, if any
+func printMalformed(c Config, r *Result) {
+	if len(r.Malformed) > 0 {
+		fmt.Printf("(!) found malformed lines: %+v\n", r.Malformed)
+	}
+}
+
+// printDangling prints the dangling references of a Result, if any
+func printDangling(c Config, r *Result) {
+	if len(r.DanglingRefs) > 0 {
+		fmt.Printf("(!) found references to undefined keys in %s: %+v\n", label(c.WorkingPath, r.WorkingRealPath), r.DanglingRefs)
+	}
+}
+
+// printOrderChanged prints the keys of a Result that are ordered differently,
+// if any
+func printOrderChanged(c Config, r *Result) {
+	if len(r.OrderChanged) > 0 {
+		fmt.Printf("(!) keys in %s are ordered differently to %s: %+v\n",
+			label(c.WorkingPath, r.WorkingRealPath), label(c.MasterPath, r.MasterRealPath), r.OrderChanged)
+	}
+}
+
+// printMissing prints the missing keys of a Result, grouped by prefix if
+// requested, returning whether it warned. no more than Config.MissingThreshold
+// missing keys is only noted
+func printMissing(c Config, r *Result) bool {
+	if len(r.Missing) <= c.MissingThreshold {
+		fmt.Printf("(i) %d missing keys in %s, within the threshold of %d: %+v\n",
+			len(r.Missing), label(c.WorkingPath, r.WorkingRealPath), c.MissingThreshold, r.Missing)
+		return false
+	}
+
+	heading := fmt.Sprintf("found missing keys in %s", label(c.WorkingPath, r.WorkingRealPath))
+	if c.Perspective == PerspectiveWorking {
+		heading = fmt.Sprintf("%s lacks keys defined in %s", label(c.WorkingPath, r.WorkingRealPath),
+			label(c.MasterPath, r.MasterRealPath))
+	}
+
+	if !c.Grouped {
+		fmt.Printf("(!) %s: %+v\n", heading, r.Missing)
+		return true
+	}
+
+	fmt.Printf("(!) %s:\n", heading)
+
+	groups := r.GroupByPrefix()
+	for _, prefix := range r.prefixes() {
+		if len(groups[prefix].Missing) > 0 {
+			fmt.Printf("  %s: %+v\n", prefix, groups[prefix].Missing)
+		}
+	}
+
+	return true
+}
+
+// printExtra prints the keys of a Result that only the working file has, if
+// any. they are a problem from the working perspective and noted from the
+// master's
+func printExtra(c Config, r *Result) {
+	if len(r.Extra) == 0 {
+		return
+	}
+
+	if c.Perspective == PerspectiveWorking {
+		fmt.Printf("(!) %s has keys that aren't in %s: %+v\n",
+			label(c.WorkingPath, r.WorkingRealPath), label(c.MasterPath, r.MasterRealPath), r.Extra)
+		return
+	}
+
+	fmt.Printf("(i) found extra keys in %s that aren't in %s: %+v\n",
+		label(c.WorkingPath, r.WorkingRealPath), label(c.MasterPath, r.MasterRealPath), r.Extra)
+}
+
+// printDifferent prints the different keys of a Result, grouped by prefix if
+// requested
+func printDifferent(c Config, r *Result) {
+	fmt.Printf("(!) %s and %s are different. Ignore if this is intentional\n",
+		label(c.WorkingPath, r.WorkingRealPath), label(c.MasterPath, r.MasterRealPath))
+
+	if !c.Grouped {
+		fmt.Printf("%+v\n", r.Different)
+		return
+	}
+
+	groups := r.GroupByPrefix()
+	for _, prefix := range r.prefixes() {
+		if len(groups[prefix].Different) > 0 {
+			fmt.Printf("  %s: %+v\n", prefix, groups[prefix].Different)
+		}
+	}
+}
+
+// label returns a path for output, including the real path it resolves to
+// if it is a symlink
+func label(path, real string) string {
+	if real == "" || real == path {
+		return path
+	}
+
+	return fmt.Sprintf("%s (%s)", path, real)
+}
+
+// PreviewMerged writes the working config augmented with every key that is
+// missing from it but present in the master file (using the master value),
+// in the working file's native format. yaml is written as json, which is also
+// valid yaml, and toml, ini, properties, xml, hcl and registered formats
+// can't be previewed. this is read-only; no files are modified
+func PreviewMerged(c Config, w io.Writer) error {
+	var merged []byte
+
+	switch c.format() {
+	case FormatEnv, FormatJson, FormatYaml, FormatURLQuery:
+	default:
+		return fmt.Errorf("can't preview merged %s files", c.format())
+	}
+
+	if c.format().nested() {
+		analyzer, err := newJsonAnalyzer(c)
+		if err != nil {
+			return err
+		}
+
+		if merged, err = analyzer.merged(); err != nil {
+			return err
+		}
+	} else {
+		analyzer, err := newEnvAnalyzer(c)
+		if err != nil {
+			return err
+		}
+
+		merged = analyzer.merged()
+	}
+
+	_, err := w.Write(merged)
+	return err
 }
 
 // connect will attempt to connect to an external host via SSH. The idea is to
 // return with an error if the connection fails, otherwise carry on until the
 // connection is made again by reading in the contents of the remote config.
-// currently this only supports connection via bash/ssh
+// this connects via the local ssh binary, see Config.SSHHost for a native
+// connection
 func (a *analyzer) connect(hostAlias string) error {
 
-	a.bash = newBash(hostAlias)
+	a.bash = newBash(hostAlias, a.config.ProxyJump)
+
+	connections.wait(a.config.MaxConnectionsPerSecond)
 
-	if err := a.bash.ssh(); err != nil {
-		fmt.Errorf("could not connect to host %s. %s", hostAlias, err)
+	a.log.Info("connecting to host", "host", hostAlias)
+
+	if err := a.bash.ssh(a.ctx); err != nil {
+		a.log.Error("could not connect to host", "host", hostAlias, "error", err)
+		return fmt.Errorf("could not connect to %s. %s", hostAlias, err)
 	}
 
 	return nil
 }
 
-// read will read a config file to []byte
+// read will read a config file to []byte. the working and master files are
+// read concurrently, as fetching a remote master can be slow, returning
+// whichever error occurs first
 func (a *analyzer) read(workingPath, masterPath string) error {
+	errs := make(chan error, 2)
+
+	go func() { errs <- a.readWorking(workingPath) }()
+	go func() { errs <- a.loadMaster(masterPath) }()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+
+	return a.checkConflicts(workingPath, masterPath)
+}
 
+// checkConflicts returns an error if either file holds unresolved merge
+// conflict markers
+func (a *analyzer) checkConflicts(workingPath, masterPath string) error {
+	if err := checkConflicts(a.working, workingPath); err != nil {
+		return err
+	}
+
+	if masterPath == "" {
+		masterPath = "master"
+	}
+
+	return checkConflicts(a.master, masterPath)
+}
+
+// readWorking will read the working file to []byte, locally or from within
+// a docker container
+func (a *analyzer) readWorking(workingPath string) error {
+	b, err := a.readRawWorking(workingPath)
+	if err != nil {
+		return err
+	}
+
+	return a.setWorking(b, workingPath)
+}
+
+// readRawWorking reads the working file as it is, before it's decoded
+func (a *analyzer) readRawWorking(workingPath string) ([]byte, error) {
+
+	var b []byte
 	var err error
 
-	a.working, err = ioutil.ReadFile(workingPath)
+	start := time.Now()
+
+	switch {
+	// we have a container. read in the contents via docker exec
+	case a.config.Container != "":
+		b, err = newDocker(a.config.Container).cat(workingPath)
+
+	default:
+		if a.workingReal, err = resolve(workingPath); err != nil {
+			break
+		}
+
+		if formatOf(a.workingReal) == FormatDir {
+			b, err = readDir(a.workingReal, a.config.format())
+		} else {
+			b, err = ioutil.ReadFile(a.workingReal)
+		}
+	}
+
 	if err != nil {
-		return fmt.Errorf("could not open %s. %s", workingPath, err)
+		a.log.Error("could not read working file", "path", workingPath, "error", err)
+		return nil, fmt.Errorf("could not open %s. %s", workingPath, err)
 	}
 
+	a.log.Debug("read working file", "path", workingPath,
+		"bytes", len(b), "duration", time.Since(start))
+
+	return b, nil
+}
+
+// setWorking decodes the raw bytes of the working file, wherever they were
+// read from
+func (a *analyzer) setWorking(b []byte, workingPath string) (err error) {
+	a.working, err = a.decode(b, workingPath)
+	return err
+}
+
+// readMaster will read the master file to []byte from wherever it lives:
+// locally, on a remote host, behind a url or at a git ref
+func (a *analyzer) readMaster(masterPath string) error {
+
+	var err error
+
+	start := time.Now()
+	source := "file"
+
+	switch {
+	// we have an environment variable holding the contents
+	case a.config.MasterFromEnv != "":
+		source = "env"
+		masterPath = "$" + a.config.MasterFromEnv
+		a.master, err = lookupEnv(a.config.MasterFromEnv)
+
 	// we have a remote file. read in the contents via scp
-	if a.bash != nil {
-		a.master, err = a.bash.scp(masterPath)
-		if err != nil {
-			return fmt.Errorf("could not open %s. %s", masterPath, err)
+	case a.bash != nil:
+		source = "scp"
+		connections.wait(a.config.MaxConnectionsPerSecond)
+		a.master, err = a.bash.scp(a.ctx, masterPath)
+
+	// we have a remote file. read in the contents via native ssh and sftp
+	case a.sftp != nil:
+		source = "sftp"
+		connections.wait(a.config.MaxConnectionsPerSecond)
+		a.master, err = a.sftp.get(a.ctx, masterPath)
+
+	// we have a url. read in the contents via http(s)
+	case a.http != nil:
+		source = "http"
+		a.master, err = a.http.get(a.ctx, masterPath)
+
+	// we have a git ref. read in the contents as committed via git show
+	case a.config.MasterGitRef != "":
+		source = "git"
+		if a.config.MasterGitPath != "" {
+			masterPath = a.config.MasterGitPath
 		}
+		a.master, err = gitShow(a.config.MasterGitRef, masterPath)
+		masterPath = a.config.MasterGitRef + ":" + masterPath
 
-		return nil
+	default:
+		if a.masterReal, err = resolve(masterPath); err != nil {
+			break
+		}
+
+		if formatOf(a.masterReal) == FormatDir {
+			source = "dir"
+			a.master, err = readDir(a.masterReal, a.config.format())
+		} else {
+			a.master, err = ioutil.ReadFile(a.masterReal)
+		}
 	}
 
-	a.master, err = ioutil.ReadFile(masterPath)
 	if err != nil {
+		a.log.Error("could not read master file", "path", masterPath,
+			"source", source, "error", err)
 		return fmt.Errorf("could not open %s. %s", masterPath, err)
 	}
 
-	return nil
+	a.log.Info("fetched master file", "path", masterPath, "source", source,
+		"bytes", len(a.master), "duration", time.Since(start))
+
+	return a.setMaster(a.master, masterPath)
+}
+
+// setMaster decompresses a gzipped master and decodes the raw bytes of the
+// master file, wherever they were read from
+func (a *analyzer) setMaster(b []byte, masterPath string) (err error) {
+	// a gzipped master is decompressed transparently
+	if strings.HasSuffix(masterPath, ".gz") {
+		if b, err = gunzip(b); err != nil {
+			a.log.Error("could not read master file", "path", masterPath, "error", err)
+			return fmt.Errorf("could not open %s. %s", masterPath, err)
+		}
+	}
+
+	a.master, err = a.decode(b, masterPath)
+	return err
+}
+
+// decrypt runs the raw bytes of a file through Config.DecryptFunc, if any,
+// before they are parsed
+func (a *analyzer) decrypt(b []byte, path string) ([]byte, error) {
+	if a.config.DecryptFunc == nil {
+		return b, nil
+	}
+
+	decrypted, err := a.config.DecryptFunc(b)
+	if err != nil {
+		a.log.Error("could not decrypt file", "path", path, "error", err)
+		return nil, fmt.Errorf("could not decrypt %s. %s", path, err)
+	}
+
+	return decrypted, nil
+}
+
+// decode prepares the raw bytes of a file for parsing, decrypting them and
+// converting yaml and toml to json
+func (a *analyzer) decode(b []byte, path string) ([]byte, error) {
+	b, err := a.decrypt(b, path)
+	if err != nil {
+		return nil, err
+	}
+
+	fromYaml := yamlToJson
+	if a.config.ResolveRefs {
+		fromYaml = yamlIncludesToJson
+	}
+
+	convert := map[Format]func([]byte) ([]byte, error){
+		FormatYaml:       fromYaml,
+		FormatToml:       tomlToJson,
+		FormatIni:        iniToJson,
+		FormatProperties: propertiesToJson,
+		FormatHcl:        hclToJson,
+		FormatXml: func(b []byte) ([]byte, error) {
+			return xmlToJson(b, a.config.XmlLists)
+		},
+	}[a.config.fileFormat(path)]
+
+	if p := a.config.fileFormat(path).parser(); p != nil {
+		convert = func(b []byte) ([]byte, error) { return parse(p, b) }
+	}
+
+	if convert == nil {
+		return b, nil
+	}
+
+	if b, err = convert(b); err != nil {
+		return nil, fmt.Errorf("could not parse %s. %s", path, err)
+	}
+
+	return b, nil
+}
+
+// scan loads the analyzer for the given format from the working and master
+// files already read into a, then scans them returning a Result
+func (a *analyzer) scan(format Format) (*Result, error) {
+	if format.nested() {
+		analyzer, err := loadJsonAnalyzer(a)
+		if err != nil {
+			return nil, err
+		}
+
+		analyzer.scan()
+
+		return analyzer.result(), nil
+	}
+
+	load := loadEnvAnalyzer
+	if format == FormatURLQuery {
+		load = loadQueryAnalyzer
+	}
+
+	analyzer, err := load(a)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer.scan()
+
+	return analyzer.result(), nil
+}
+
+// result returns the outcome of a scan
+func (a analyzer) result() *Result {
+	r := &Result{
+		WorkingPath:      a.config.WorkingPath,
+		WorkingRealPath:  a.workingReal,
+		MasterPath:       a.config.MasterPath,
+		MasterRealPath:   a.masterReal,
+		Missing:          a.missing,
+		Extra:            a.extra,
+		Forbidden:        a.forbidden,
+		DanglingRefs:     a.dangling,
+		OrderChanged:     a.orderChanged,
+		Malformed:        a.malformed,
+		UsingDefault:     a.usingDefault,
+		TypeWarnings:     a.typeWarnings,
+		TypeMismatches:   a.mismatches,
+		OptionalSections: a.optional,
+		ShapeChanged:     a.shapeChanged,
+		CommentChanged:   a.comments,
+		Different:        a.different,
+		ElementChanges:   a.elements,
+		ScannedAt:        a.started,
+		Duration:         time.Since(a.started),
+	}
+
+	for _, key := range a.missing {
+		if a.required[key] {
+			r.MissingRequired = append(r.MissingRequired, key)
+		} else {
+			r.MissingWithDefault = append(r.MissingWithDefault, key)
+		}
+	}
+
+	// note which of several masters each missing or different key came from
+	if a.sources != nil {
+		r.MissingSources = map[string]string{}
+		for _, key := range a.missing {
+			r.MissingSources[key] = a.sources[key]
+		}
+
+		r.Different = make([]DiffEntry, len(a.different))
+		for i, d := range a.different {
+			d.SourceFile = a.sources[d.Key]
+			r.Different[i] = d
+		}
+	}
+
+	return r
+}
+
+// lookupEnv returns the contents of an environment variable
+func lookupEnv(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return []byte(value), nil
+}
+
+// resolve returns the real path of a local file, following any symlinks. a
+// symlink pointing at a file that doesn't exist is reported as such
+func resolve(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return real, nil
+	}
+
+	if info, lerr := os.Lstat(path); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(path)
+		return "", fmt.Errorf("symlink target missing: %s", target)
+	}
+
+	return "", err
+}
+
+// gunzip decompresses gzipped bytes
+func gunzip(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// normalize substitutes known environment tokens within a value so that
+// values differing only by environment name compare as equal. tokens are
+// applied longest first to keep the substitution deterministic
+func (a analyzer) normalize(value string) string {
+	tokens := []string{}
+	for token := range a.config.EnvTokenMap {
+		tokens = append(tokens, token)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if len(tokens[i]) != len(tokens[j]) {
+			return len(tokens[i]) > len(tokens[j])
+		}
+		return tokens[i] < tokens[j]
+	})
+
+	for _, token := range tokens {
+		value = strings.Replace(value, token, a.config.EnvTokenMap[token], -1)
+	}
+
+	return value
+}
+
+// equalValues determines whether a master and working value for the given key
+// are equal once normalized, comparing list values as sets
+func (a analyzer) equalValues(key, master, working string) bool {
+	if a.accepts(master, working) {
+		return true
+	}
+
+	master, working = a.normalize(master), a.normalize(working)
+
+	if a.config.CoerceScalars {
+		master, working = coerce(master), coerce(working)
+	}
+
+	if match(a.config.ListValueKeys, key) {
+		return a.listSet(master) == a.listSet(working)
+	}
+
+	if match(a.config.Base64Keys, key) {
+		m, mok := decodeBase64(master)
+		w, wok := decodeBase64(working)
+		if mok && wok {
+			return bytes.Equal(m, w)
+		}
+	}
+
+	return master == working
+}
+
+// coerce returns the canonical text of a scalar so that values of different
+// types compare equal, e.g. yes, on and TRUE become true and 1.0 and 1e0
+// become 1. other values are returned as is
+func coerce(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "on":
+		return "true"
+	case "false", "no", "off":
+		return "false"
+	}
+
+	if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+
+	return value
+}
+
+// decodeBase64 decodes a standard or url safe base64 value, ignoring padding
+// and any whitespace from line wrapping
+func decodeBase64(value string) ([]byte, bool) {
+	value = strings.Join(strings.Fields(value), "")
+	value = strings.TrimRight(value, "=")
+
+	if b, err := base64.RawStdEncoding.DecodeString(value); err == nil {
+		return b, true
+	}
+
+	if b, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return b, true
+	}
+
+	return nil, false
+}
+
+// accepts determines if a working value is acceptable regardless of what it
+// is, either because the master value is a placeholder or because both values
+// match one of Config.IgnoreValuePatterns
+func (a analyzer) accepts(master, working string) bool {
+	if a.placeholder(master) {
+		return true
+	}
+
+	for _, re := range a.ignore {
+		if re.MatchString(master) && re.MatchString(working) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// placeholder determines if a master value is one of Config.Placeholders.
+// a value equal to a pattern matches it, so literal placeholders such as
+// "[CHANGE ME]" needn't escape their glob characters
+func (a analyzer) placeholder(value string) bool {
+	for _, pattern := range a.config.Placeholders {
+		if value == pattern {
+			return true
+		}
+	}
+
+	return match(a.config.Placeholders, value)
+}
+
+// addMissing stores a key missing from the working file, noting whether its
+// master value is blank
+func (a *analyzer) addMissing(key string, master interface{}) {
+	a.missing = append(a.missing, key)
+
+	if a.blank(master) {
+		if a.required == nil {
+			a.required = map[string]bool{}
+		}
+		a.required[key] = true
+	}
+}
+
+// blank determines if a master value is empty, null or a placeholder, so the
+// working file must provide its own
+func (a analyzer) blank(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == "" || a.placeholder(v)
+	}
+
+	return false
+}
+
+// listSet splits a list value by the configured delimiter, returning its
+// unique elements sorted and re-joined so two sets can be compared as strings
+func (a analyzer) listSet(value string) string {
+	delimiter := a.config.ListDelimiter
+	if delimiter == "" {
+		delimiter = ","
+	}
+
+	set := map[string]bool{}
+	for _, element := range strings.Split(value, delimiter) {
+		set[strings.TrimSpace(element)] = true
+	}
+
+	elements := []string{}
+	for element := range set {
+		elements = append(elements, element)
+	}
+
+	sort.Strings(elements)
+
+	return strings.Join(elements, delimiter)
+}
+
+// findForbidden stores the working keys that match Config.ForbiddenKeys
+func (a *analyzer) findForbidden(keys []string) {
+	for _, key := range keys {
+		if matchPath(a.config.ForbiddenKeys, key, a.config.keySeparator()) {
+			a.forbidden = append(a.forbidden, key)
+		}
+	}
+}
+
+// orderChanged compares two orderings of the same keys, returning those
+// whose position differs
+func orderChanged(master, working []string) []string {
+	changed := []string{}
+
+	for i := range master {
+		if i < len(working) && master[i] != working[i] {
+			changed = append(changed, master[i])
+		}
+	}
+
+	return changed
+}
+
+// match determines if a key matches any of the given glob patterns
+func match(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath determines if a path, its keys joined by sep, matches any of the
+// given glob patterns. path.Match reads the brackets of a key containing sep,
+// e.g. [a.b].c, as a character class, so a pattern holding brackets is also
+// matched key by key, its bracketed keys matching literally
+func matchPath(patterns []string, key, sep string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+
+		if strings.Contains(pattern, "[") && matchKeys(splitPath(pattern, sep), splitPath(key, sep)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKeys determines if each key matches the pattern at its position
+func matchKeys(patterns, keys []string) bool {
+	if len(patterns) != len(keys) {
+		return false
+	}
+
+	for i := range keys {
+		if ok, _ := path.Match(patterns[i], keys[i]); !ok && patterns[i] != keys[i] {
+			return false
+		}
+	}
+	return true
 }
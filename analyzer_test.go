@@ -1,7 +1,24 @@
 package cfg
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewAnalzer(t *testing.T) {
@@ -32,7 +49,7 @@ func TestScanEnv(t *testing.T) {
 	actual := len(keys)
 
 	if actual != expected {
-		t.Fatalf("expected=%d actual", expected, actual)
+		t.Fatalf("expected=%d actual=%d", expected, actual)
 	}
 }
 
@@ -62,7 +79,72 @@ func TestScanJson(t *testing.T) {
 	actual := len(keys)
 
 	if actual != expected {
-		t.Fatalf("expected=%d actual", expected, actual)
+		t.Fatalf("expected=%d actual=%d", expected, actual)
+	}
+}
+
+func TestScanJsonStreamed(t *testing.T) {
+	dir := t.TempDir()
+	docs := map[string]string{
+		"shapes.json":  `{"a":{"b":1},"c":[{"d":1},{"e":2}],"f":[[{"g":1}]],"h":1,"i":{"j":{"k":1}}}`,
+		"changed.json": `{"a":1,"c":[{"d":1}],"f":[[{}]],"h":{"x":1},"i":{"j":{}}}`,
+	}
+
+	for name, doc := range docs {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := filepath.Glob("test/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = append(paths, "test/arrays/working.json", "test/arrays/master.json",
+		filepath.Join(dir, "shapes.json"), filepath.Join(dir, "changed.json"))
+
+	// the streamed keys are those found by parsing both files in full
+	for _, working := range paths {
+		for _, master := range paths {
+			c := Config{WorkingPath: working, MasterPath: master, AllowSamePath: true}
+
+			result, err := Scan(c)
+			if err != nil {
+				continue
+			}
+
+			w, err := ioutil.ReadFile(working)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := ioutil.ReadFile(master)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := &analyzer{config: c, working: w, master: m}
+
+			streamed, err := a.streamedMissing()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Strings(result.Missing)
+
+			if !reflect.DeepEqual(streamed, result.Missing) {
+				t.Fatalf("working=%s master=%s expected=%v actual=%v", working, master, result.Missing, streamed)
+			}
+
+			keys, err := ScanJson(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(keys, result.Missing) {
+				t.Fatalf("working=%s master=%s expected=%v actual=%v", working, master, result.Missing, keys)
+			}
+		}
 	}
 }
 
@@ -76,3 +158,838 @@ func TestPrintJson(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestScanEnvHttp(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("test")))
+	defer server.Close()
+
+	c := Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  server.URL + "/b.env",
+	}
+
+	keys, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected=%d actual=%d", 3, len(keys))
+	}
+
+	c.MaxBytes = 10
+	if _, err := ScanEnv(c); err == nil {
+		t.Fatal("expected master to exceed max size")
+	}
+}
+
+func TestScanReadFirstError(t *testing.T) {
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c := Config{
+		WorkingPath: "test/missing.env",
+		MasterPath:  server.URL + "/b.env",
+	}
+
+	// the working file error is returned without waiting on the master
+	_, err := Scan(c)
+	if err == nil || !strings.Contains(err.Error(), "test/missing.env") {
+		t.Fatalf("expected the working file error actual=%v", err)
+	}
+}
+
+func TestPreviewMergedEnv(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  "test/b.env",
+	}
+
+	var buf bytes.Buffer
+	if err := PreviewMerged(c, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "FRUIT=Mango\nANIMAL=Koala\nSPORT=Football\nFOOD=Pizza\nLANG=Go\nDRINK=Soda\n"
+	if buf.String() != expected {
+		t.Fatalf("expected=%q actual=%q", expected, buf.String())
+	}
+}
+
+func TestPreviewMergedJson(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/a.json",
+		MasterPath:  "test/b.json",
+	}
+
+	var buf bytes.Buffer
+	if err := PreviewMerged(c, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	merged := jsoncfg{}
+	if err := json.Unmarshal(buf.Bytes(), &merged); err != nil {
+		t.Fatal(err)
+	}
+
+	if merged["6"] != true {
+		t.Fatalf("expected=%t actual=%v", true, merged["6"])
+	}
+
+	if merged["3"].(map[string]interface{})["5"] != float64(1) {
+		t.Fatalf("expected=%d actual=%v", 1, merged["3"])
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  "test/b.env",
+		Logger:      slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	if _, err := ScanEnv(c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"read working file", "fetched master file", "parsed env config"} {
+		if !strings.Contains(buf.String(), msg) {
+			t.Fatalf("expected log output to contain %q", msg)
+		}
+	}
+
+	if !strings.Contains(buf.String(), `"master_keys":6`) {
+		t.Fatalf("expected parse counts in log output: %s", buf.String())
+	}
+}
+
+func TestScanResultVariants(t *testing.T) {
+	tests := []struct {
+		scan            func(Config) (*Result, error)
+		working, master string
+		missing         int
+	}{
+		{ScanEnvResult, "test/a.env", "test/b.env", 3},
+		{ScanJsonResult, "test/a.json", "test/b.json", 2},
+		{ScanYamlResult, "test/yaml/working.yml", "test/yaml/master.yaml", 3},
+		{ScanTomlResult, "test/toml/working.toml", "test/toml/master.toml", 3},
+	}
+
+	for _, tt := range tests {
+		result, err := tt.scan(Config{WorkingPath: tt.working, MasterPath: tt.master})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Missing) != tt.missing {
+			t.Fatalf("working=%s expected=%d actual=%d", tt.working, tt.missing, len(result.Missing))
+		}
+	}
+
+	// the format is set by the variant rather than the file extension
+	if _, err := ScanJsonResult(Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"}); err == nil {
+		t.Fatal("expected env files to fail to parse as json")
+	}
+}
+
+func TestScan(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/c.env",
+		MasterPath:  "test/d.env",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 0 {
+		t.Fatalf("expected=%d actual=%d", 0, len(result.Missing))
+	}
+
+	if len(result.Different) != 1 || result.Different[0].String() != "SPORT=Football" {
+		t.Fatalf("expected=%s actual=%+v", "SPORT=Football", result.Different)
+	}
+
+	if result.ScannedAt.IsZero() || time.Since(result.ScannedAt) < result.Duration {
+		t.Fatalf("expected scan timing actual=%s %s", result.ScannedAt, result.Duration)
+	}
+}
+
+func TestScanEnvGzip(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  "test/b.env.gz",
+	}
+
+	keys, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected=%d actual=%d", 3, len(keys))
+	}
+}
+
+func TestPrintEnvGrouped(t *testing.T) {
+	dir := t.TempDir()
+	working, master := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.example")
+
+	if err := ioutil.WriteFile(master, []byte("DB_HOST=localhost\nDB_PORT=5432\nDB_USER=app\nCACHE_TTL=60\nCACHE_SIZE=10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: working,
+		MasterPath:  master,
+		Grouped:     true,
+	}
+
+	tests := []struct {
+		working  string
+		expected string
+	}{
+		{"DB_HOST=db\nCACHE_TTL=30\n", "(!) found missing keys in " + working + ":\n  CACHE: [CACHE_SIZE]\n  DB: [DB_PORT DB_USER]\n"},
+		{"DB_HOST=db\nDB_PORT=5432\nDB_USER=app\nCACHE_TTL=30\nCACHE_SIZE=10\n", "  CACHE: [CACHE_TTL=30]\n  DB: [DB_HOST=db]\n"},
+	}
+
+	for _, tt := range tests {
+		if err := ioutil.WriteFile(working, []byte(tt.working), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var err error
+		out := captureStdout(t, func() { err = PrintEnv(c) })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(out, tt.expected) {
+			t.Fatalf("expected=%s actual=%s", tt.expected, out)
+		}
+	}
+}
+
+func TestPrintMissingThreshold(t *testing.T) {
+	r := &Result{Missing: []string{"FRUIT", "ANIMAL"}}
+
+	if printMissing(Config{WorkingPath: "test/a.env", MissingThreshold: 2}, r) {
+		t.Fatal("expected no warning within the threshold")
+	}
+
+	if !printMissing(Config{WorkingPath: "test/a.env", MissingThreshold: 1}, r) {
+		t.Fatal("expected a warning above the threshold")
+	}
+
+	c := Config{
+		WorkingPath:      "test/a.env",
+		MasterPath:       "test/b.env",
+		MissingThreshold: 5,
+	}
+
+	if err := PrintEnv(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanSymlink(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	link := filepath.Join(dir, ".env")
+	if err := os.Symlink(filepath.Join(wd, "test/a.env"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: link,
+		MasterPath:  "test/b.env",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.WorkingPath != link {
+		t.Fatalf("expected=%s actual=%s", link, result.WorkingPath)
+	}
+
+	if result.WorkingRealPath != filepath.Join(wd, "test/a.env") {
+		t.Fatalf("expected=%s actual=%s", filepath.Join(wd, "test/a.env"), result.WorkingRealPath)
+	}
+
+	broken := filepath.Join(dir, "broken.env")
+	if err := os.Symlink(filepath.Join(dir, "missing.env"), broken); err != nil {
+		t.Fatal(err)
+	}
+
+	c.WorkingPath = broken
+	if _, err := Scan(c); err == nil || !strings.Contains(err.Error(), "symlink target missing") {
+		t.Fatalf("expected a symlink target missing error actual=%v", err)
+	}
+}
+
+func TestScanSamePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(t.TempDir(), ".env")
+	if err := os.Symlink(filepath.Join(wd, "test/a.env"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: link,
+		MasterPath:  "test/a.env",
+	}
+
+	if _, err := Scan(c); err == nil || !strings.Contains(err.Error(), "point to the same file") {
+		t.Fatalf("expected a same file error actual=%v", err)
+	}
+
+	c.AllowSamePath = true
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 0 || len(result.Different) != 0 {
+		t.Fatalf("expected no differences actual=%+v", result)
+	}
+}
+
+func TestScanMasterFromEnv(t *testing.T) {
+	t.Setenv("CFG_TEST_MASTER", `{"1": true, "2": false, "7": {"8": true}}`)
+
+	c := Config{
+		WorkingPath:   "test/a.json",
+		MasterFromEnv: "CFG_TEST_MASTER",
+		Format:        FormatJson,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "7" {
+		t.Fatalf("expected=[7] actual=%+v", result.Missing)
+	}
+
+	c.MasterFromEnv = "CFG_TEST_UNSET"
+	if _, err := Scan(c); err == nil {
+		t.Fatal("expected an error for an unset environment variable")
+	}
+}
+
+func TestScanMasterGlob(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/backups/config.json",
+		MasterGlob:  "test/backups/config.*.json",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.MasterPath != "test/backups/config.2024-06-02.json" {
+		t.Fatalf("expected the latest backup actual=%s", result.MasterPath)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "debug" {
+		t.Fatalf("expected=[debug] actual=%+v", result.Missing)
+	}
+
+	c.MasterGlob = "test/backups/nope.*.json"
+	if _, err := Scan(c); err == nil {
+		t.Fatal("expected an error for a glob matching nothing")
+	}
+}
+
+func TestScanWorkingsMasterGlob(t *testing.T) {
+	c := Config{MasterGlob: "test/backups/config.*.json"}
+
+	results, err := ScanWorkings([]string{"test/backups/config.json"}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := results["test/backups/config.json"]
+	if r.MasterPath != "test/backups/config.2024-06-02.json" {
+		t.Fatalf("expected the latest backup actual=%s", r.MasterPath)
+	}
+
+	if len(r.Missing) != 1 || r.Missing[0] != "debug" {
+		t.Fatalf("expected=[debug] actual=%+v", r.Missing)
+	}
+}
+
+func TestScanDecryptFunc(t *testing.T) {
+	// a stand in for real decryption: the "encrypted" fixture is base64
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "b.env.enc")
+
+	master, err := ioutil.ReadFile("test/b.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(encrypted, []byte(base64.StdEncoding.EncodeToString(master)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  encrypted,
+		Format:      FormatEnv,
+		DecryptFunc: func(b []byte) ([]byte, error) {
+			if decoded, err := base64.StdEncoding.DecodeString(string(b)); err == nil {
+				return decoded, nil
+			}
+			// the working file is stored in plain text
+			return b, nil
+		},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 3 {
+		t.Fatalf("expected=%d actual=%d", 3, len(result.Missing))
+	}
+
+	c.DecryptFunc = func(b []byte) ([]byte, error) {
+		return nil, errors.New("no key")
+	}
+
+	if _, err := Scan(c); err == nil {
+		t.Fatal("expected a decryption error")
+	}
+}
+
+func TestScanWorkings(t *testing.T) {
+	// test/b.env is scanned against itself
+	c := Config{MasterPath: "test/b.env", AllowSamePath: true}
+
+	results, err := ScanWorkings([]string{"test/a.env", "test/r.env", "test/b.env"}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		missing int
+	}{
+		{"test/a.env", 3},
+		{"test/r.env", 2},
+		{"test/b.env", 0},
+	}
+
+	for _, tt := range tests {
+		r, ok := results[tt.path]
+		if !ok {
+			t.Fatalf("expected a result for %s", tt.path)
+		}
+
+		if len(r.Missing) != tt.missing {
+			t.Fatalf("path=%s expected=%d actual=%d", tt.path, tt.missing, len(r.Missing))
+		}
+
+		if r.WorkingPath != tt.path {
+			t.Fatalf("expected=%s actual=%s", tt.path, r.WorkingPath)
+		}
+	}
+}
+
+func TestScanWorkingsErrors(t *testing.T) {
+	c := Config{MasterPath: "test/b.json"}
+	paths := []string{"test/a.json", "test/h.json", "test/none.json"}
+
+	results, err := ScanWorkings(paths, c)
+
+	errs, ok := err.(ScanErrors)
+	if !ok || len(errs) != 2 || errs["test/h.json"] == nil || errs["test/none.json"] == nil {
+		t.Fatalf("expected errors for test/h.json and test/none.json actual=%v", err)
+	}
+
+	if !strings.HasPrefix(err.Error(), "could not scan 2 files. test/h.json: ") {
+		t.Fatalf("expected the errors ordered by file actual=%s", err)
+	}
+
+	// the files that could be scanned are still compared
+	if r := results["test/a.json"]; r == nil || r.Error != "" || len(r.Missing) != 2 {
+		t.Fatalf("expected a result for test/a.json actual=%+v", r)
+	}
+
+	for _, path := range []string{"test/h.json", "test/none.json"} {
+		if r := results[path]; r == nil || r.Error != errs[path].Error() || r.WorkingPath != path {
+			t.Fatalf("expected the error in the result of %s actual=%+v", path, r)
+		}
+	}
+
+	c.FailFast = true
+
+	results, err = ScanWorkings(paths, c)
+	if _, ok := err.(ScanErrors); ok || err == nil || results != nil {
+		t.Fatalf("expected the first error only actual=%v", err)
+	}
+}
+
+func TestScanWorkingsSamePath(t *testing.T) {
+	c := Config{MasterPath: "test/b.env"}
+
+	results, err := ScanWorkings([]string{"test/a.env", "test/b.env"}, c)
+	if err == nil || !strings.Contains(err.Error(), "point to the same file") {
+		t.Fatalf("expected a same file error actual=%v", err)
+	}
+
+	if r := results["test/a.env"]; r == nil || r.Error != "" {
+		t.Fatalf("expected test/a.env to be scanned actual=%+v", r)
+	}
+}
+
+func TestScanWorkingsValidate(t *testing.T) {
+	c := Config{MasterPath: "test/b.env", MasterFromEnv: "MASTER"}
+
+	expected := "invalid config. MasterPath and MasterFromEnv are mutually exclusive"
+	if _, err := ScanWorkings([]string{"test/a.env"}, c); err == nil || err.Error() != expected {
+		t.Fatalf("expected=%s actual=%v", expected, err)
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		ok       bool
+	}{
+		{"aGVsbG8gd29ybGQ=", "hello world", true},
+		{"aGVsbG8gd29ybGQ", "hello world", true},
+		{"aGVsbG8g\nd29ybGQ=", "hello world", true},
+		{"-_8", "\xfb\xff", true},
+		{"not base64!", "", false},
+	}
+
+	for _, tt := range tests {
+		b, ok := decodeBase64(tt.value)
+		if ok != tt.ok || ok && string(b) != tt.expected {
+			t.Fatalf("value=%s expected=%q actual=%q", tt.value, tt.expected, b)
+		}
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"yes", "true"},
+		{"ON", "true"},
+		{"no", "false"},
+		{"1.0", "1"},
+		{"1e3", "1000"},
+		{"mango", "mango"},
+	}
+
+	for _, tt := range tests {
+		if actual := coerce(tt.value); actual != tt.expected {
+			t.Fatalf("value=%s expected=%s actual=%s", tt.value, tt.expected, actual)
+		}
+	}
+}
+
+func TestIgnoreExtra(t *testing.T) {
+	tests := []struct {
+		working, master string
+		print           func(Config) error
+	}{
+		{"test/b.env", "test/a.env", PrintEnv},
+		{"test/b.json", "test/a.json", PrintJson},
+	}
+
+	for _, tt := range tests {
+		c := Config{WorkingPath: tt.working, MasterPath: tt.master}
+
+		result, err := Scan(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Extra) == 0 {
+			t.Fatalf("working=%s expected extra keys", tt.working)
+		}
+
+		out := captureStdout(t, func() { err = tt.print(c) })
+		if err != nil || !strings.Contains(out, "(i) found extra keys in "+tt.working) {
+			t.Fatalf("expected the extra keys to be printed actual=%s", out)
+		}
+
+		c.IgnoreExtra = true
+
+		if result, err = Scan(c); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Extra) != 0 {
+			t.Fatalf("working=%s expected no extra keys actual=%v", tt.working, result.Extra)
+		}
+
+		out = captureStdout(t, func() { err = tt.print(c) })
+		if err != nil || strings.Contains(out, "found extra keys") {
+			t.Fatalf("expected no extra keys to be printed actual=%s", out)
+		}
+	}
+}
+
+// captureStdout returns what f prints to stdout
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	w.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestPrintEnvPerspective(t *testing.T) {
+	dir := t.TempDir()
+	working, master := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.example")
+
+	if err := ioutil.WriteFile(working, []byte("FRUIT=Mango\nSPORT=Rugby\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(master, []byte("FRUIT=Mango\nANIMAL=Koala\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: working,
+		MasterPath:  master,
+		Perspective: PerspectiveWorking,
+	}
+
+	var err error
+	out := captureStdout(t, func() { err = PrintEnv(c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extra := strings.Index(out, "has keys that aren't in")
+	lacks := strings.Index(out, "lacks keys defined in")
+
+	if extra < 0 || lacks < 0 || lacks < extra {
+		t.Fatalf("expected extra keys to lead missing keys actual=%s", out)
+	}
+
+	// from the master's perspective the missing keys lead
+	c.Perspective = PerspectiveMaster
+
+	out = captureStdout(t, func() { err = PrintEnv(c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extra = strings.Index(out, "found extra keys in")
+	missing := strings.Index(out, "found missing keys in")
+
+	if extra < 0 || missing < 0 || extra < missing {
+		t.Fatalf("expected missing keys to lead extra keys actual=%s", out)
+	}
+
+	c.WorkingPath, c.MasterPath = filepath.Join(dir, "working.json"), filepath.Join(dir, "master.json")
+
+	if err := ioutil.WriteFile(c.WorkingPath, []byte(`{"fruit":"Mango","sport":"Rugby"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(c.MasterPath, []byte(`{"fruit":"Mango","animal":"Koala"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out = captureStdout(t, func() { err = PrintJson(c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extra = strings.Index(out, "found extra keys in")
+	missing = strings.Index(out, "found missing keys in")
+
+	if extra < 0 || missing < 0 || extra < missing {
+		t.Fatalf("expected missing keys to lead extra keys actual=%s", out)
+	}
+
+	c.Perspective = "sideways"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an error for an unsupported perspective")
+	}
+}
+
+func TestConcurrentScans(t *testing.T) {
+	configs := []Config{
+		{WorkingPath: "test/a.env", MasterPath: "test/b.env"},
+		{WorkingPath: "test/a.json", MasterPath: "test/b.json"},
+		{WorkingPath: "test/m.env", MasterPath: "test/g.json", EnvSeparator: "_"},
+		{WorkingPath: "test/a.env", MasterPaths: []string{"test/b.env", "test/c.env"}},
+	}
+
+	expected := make([]*Result, len(configs))
+	for i, c := range configs {
+		r, err := Scan(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected[i] = r
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50*len(configs))
+
+	for n := 0; n < 50; n++ {
+		for i, c := range configs {
+			wg.Add(1)
+			go func(i int, c Config) {
+				defer wg.Done()
+
+				r, err := Scan(c)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if len(r.Missing) != len(expected[i].Missing) || len(r.Different) != len(expected[i].Different) {
+					errs <- fmt.Errorf("expected=%+v actual=%+v", expected[i], r)
+				}
+			}(i, c)
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		captureStdout(t, func() {
+			var print sync.WaitGroup
+			for n := 0; n < 10; n++ {
+				print.Add(1)
+				go func() {
+					defer print.Done()
+					PrintEnv(configs[0])
+				}()
+			}
+			print.Wait()
+		})
+	}()
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestScanContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c := Config{WorkingPath: "test/a.env", MasterPath: server.URL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := ScanEnvContext(ctx, c)
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("expected a deadline error actual=%v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the fetch to be abandoned, took %s", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ScanContext(cancelled, c); err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("expected a cancelled error actual=%v", err)
+	}
+
+	if _, err := ScanJsonContext(cancelled, Config{WorkingPath: "test/a.json", MasterPath: server.URL + "/b.json"}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, err := ScanWorkingsContext(cancelled, []string{"test/a.env"}, Config{MasterPath: "test/b.env"}); err != context.Canceled {
+		t.Fatalf("expected=%v actual=%v", context.Canceled, err)
+	}
+
+	if _, err := NewAnalyzer(c).ScanContext(cancelled, "test/a.env"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// a local master isn't fetched with ctx
+	if _, err := ScanEnvContext(ctx, Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	a := analyzer{config: Config{Placeholders: []string{"[CHANGE ME]", "<*>", `\[set\]`}}}
+
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"[CHANGE ME]", true},
+		{"<region>", true},
+		{"[set]", true},
+
+		// patterns are still globs, so [CHANGE ME] also matches one of its letters
+		{"C", true},
+		{"us-east-1", false},
+		{"[CHANGE]", false},
+	}
+
+	for _, tt := range tests {
+		if actual := a.placeholder(tt.value); actual != tt.expected {
+			t.Fatalf("value=%s expected=%t actual=%t", tt.value, tt.expected, actual)
+		}
+	}
+}
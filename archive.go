@@ -0,0 +1,108 @@
+package cfg
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+)
+
+// ScanArchive compares each file within the WorkingPath directory against the
+// file of the same name inside the MasterPath .zip archive, e.g. a release
+// bundle of canonical config files. a Result is returned per working file name.
+// working files without a counterpart in the archive are skipped. a file
+// that can't be read or parsed doesn't stop the others being scanned, see
+// ScanWorkings
+func ScanArchive(c Config) (map[string]*Result, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.OpenReader(c.MasterPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s. %s", c.MasterPath, err)
+	}
+	defer archive.Close()
+
+	// entries are matched by base name so archives with a top level
+	// directory still line up with the working files
+	entries := map[string]*zip.File{}
+	for _, f := range archive.File {
+		name := path.Base(f.Name)
+		if _, ok := entries[name]; f.FileInfo().IsDir() || ok {
+			continue
+		}
+		entries[name] = f
+	}
+
+	files, err := ioutil.ReadDir(c.WorkingPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s. %s", c.WorkingPath, err)
+	}
+
+	results, errs := map[string]*Result{}, ScanErrors{}
+
+	for _, file := range files {
+		entry, ok := entries[file.Name()]
+		if file.IsDir() || !ok {
+			continue
+		}
+
+		fc := c
+		fc.WorkingPath = filepath.Join(c.WorkingPath, file.Name())
+		fc.MasterPath = c.MasterPath + ":" + entry.Name
+
+		a, err := initAnalyzer(fc)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := scanArchived(a, entry)
+		if err != nil {
+			if err := a.fail(results, errs, file.Name(), err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		results[file.Name()] = result
+	}
+
+	return results, errs.err()
+}
+
+// scanArchived scans the analyzer's working file against an archive entry
+func scanArchived(a *analyzer, entry *zip.File) (*Result, error) {
+	c := a.config
+
+	if err := a.readWorking(c.WorkingPath); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if a.master, err = readZipFile(entry); err != nil {
+		return nil, fmt.Errorf("could not open %s. %s", c.MasterPath, err)
+	}
+
+	if a.master, err = a.decode(a.master, c.MasterPath); err != nil {
+		return nil, err
+	}
+
+	if err := a.checkConflicts(c.WorkingPath, c.MasterPath); err != nil {
+		return nil, err
+	}
+
+	return a.scan(formatOf(filepath.Base(c.WorkingPath)))
+}
+
+// readZipFile reads the contents of a file within a zip archive
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
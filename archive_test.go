@@ -0,0 +1,47 @@
+package cfg
+
+import "testing"
+
+func TestScanArchive(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/archive",
+		MasterPath:  "test/release.zip",
+	}
+
+	results, err := ScanArchive(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// worker.env has no counterpart in the archive
+	if len(results) != 2 {
+		t.Fatalf("expected=%d actual=%d", 2, len(results))
+	}
+
+	tests := []struct {
+		name    string
+		missing int
+	}{
+		{"app.env", 3},
+		{"app.json", 2},
+	}
+
+	for _, tt := range tests {
+		if actual := len(results[tt.name].Missing); actual != tt.missing {
+			t.Fatalf("name=%s expected=%d actual=%d", tt.name, tt.missing, actual)
+		}
+	}
+}
+
+func TestScanArchiveValidate(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/archive",
+		MasterPath:  "test/release.zip",
+		Perspective: "sideways",
+	}
+
+	expected := "invalid config. unsupported perspective sideways"
+	if _, err := ScanArchive(c); err == nil || err.Error() != expected {
+		t.Fatalf("expected=%s actual=%v", expected, err)
+	}
+}
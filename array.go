@@ -0,0 +1,135 @@
+package cfg
+
+import "sort"
+
+// ArrayMode determines how two json arrays are compared
+type ArrayMode string
+
+const (
+	// ArrayPositional compares arrays element by element, the default
+	ArrayPositional ArrayMode = "positional"
+
+	// ArraySet ignores order and duplicates, so [a, a, b] equals [b, a]
+	ArraySet ArrayMode = "set"
+
+	// ArrayMultiset ignores order but counts duplicates, so [a, a, b] equals
+	// [b, a, a] but not [a, b, b]
+	ArrayMultiset ArrayMode = "multiset"
+)
+
+// arrayMode returns the mode of the first Config.ArrayModes pattern matching
+// the dotted path of an array, defaulting to ArrayPositional
+func (a analyzer) arrayMode(path string) ArrayMode {
+	patterns := []string{}
+	for pattern := range a.config.ArrayModes {
+		patterns = append(patterns, pattern)
+	}
+
+	// map order is random, so check patterns in a stable order
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if matchPath([]string{pattern}, path, a.config.keySeparator()) {
+			return a.config.ArrayModes[pattern]
+		}
+	}
+
+	return ArrayPositional
+}
+
+// elementChanges stores the elements of the working array at path that
+// aren't in the master array and those of the master array that aren't in
+// the working array, as sorted text. for an ArrayMultiset the count of each
+// element that occurs a different number of times is stored instead.
+// nothing is stored unless both are arrays of scalars
+func (a *analyzer) elementChanges(path string, mode ArrayMode, master, working interface{}) {
+	m, mok := scalars(master)
+	w, wok := scalars(working)
+	if !mok || !wok {
+		return
+	}
+
+	e := ElementDiff{}
+
+	if mode == ArrayMultiset {
+		both := append(append([]interface{}(nil), master.([]interface{})...), working.([]interface{})...)
+		for _, v := range canonical(both, ArraySet) {
+			if text := rawJson(v); m[text] != w[text] {
+				e.Counts = append(e.Counts, ElementCount{Element: text, Master: m[text], Working: w[text]})
+			}
+		}
+	} else {
+		e.Added, e.Removed = []string{}, []string{}
+
+		for _, v := range canonical(working.([]interface{}), ArraySet) {
+			if m[rawJson(v)] == 0 {
+				e.Added = append(e.Added, rawJson(v))
+			}
+		}
+
+		for _, v := range canonical(master.([]interface{}), ArraySet) {
+			if w[rawJson(v)] == 0 {
+				e.Removed = append(e.Removed, rawJson(v))
+			}
+		}
+	}
+
+	if a.config.sensitive(path) {
+		e.Added, e.Removed = redactAll(e.Added), redactAll(e.Removed)
+		for i := range e.Counts {
+			e.Counts[i].Element = redacted
+		}
+	}
+
+	if a.elements == nil {
+		a.elements = map[string]ElementDiff{}
+	}
+	a.elements[path] = e
+}
+
+// scalars returns how many times the json text of each element of an array
+// occurs, or false if v isn't an array or holds an object or array
+func scalars(v interface{}) (map[string]int, bool) {
+	s, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	counts := map[string]int{}
+	for _, e := range s {
+		switch e.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, false
+		}
+
+		counts[rawJson(e)]++
+	}
+
+	return counts, true
+}
+
+// canonical returns the elements of an array ordered so that two arrays equal
+// under the given mode are identical. duplicates are removed for ArraySet
+func canonical(s []interface{}, mode ArrayMode) []interface{} {
+	if mode != ArraySet && mode != ArrayMultiset {
+		return s
+	}
+
+	sorted := append([]interface{}(nil), s...)
+	sort.SliceStable(sorted, func(i, k int) bool {
+		return rawJson(sorted[i]) < rawJson(sorted[k])
+	})
+
+	if mode == ArrayMultiset {
+		return sorted
+	}
+
+	unique := []interface{}{}
+	for i := range sorted {
+		if i == 0 || rawJson(sorted[i]) != rawJson(sorted[i-1]) {
+			unique = append(unique, sorted[i])
+		}
+	}
+
+	return unique
+}
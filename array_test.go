@@ -0,0 +1,116 @@
+package cfg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	s := []interface{}{"b", "a", "b"}
+
+	tests := []struct {
+		mode     ArrayMode
+		expected string
+	}{
+		{ArrayPositional, `["b","a","b"]`},
+		{ArraySet, `["a","b"]`},
+		{ArrayMultiset, `["a","b","b"]`},
+	}
+
+	for _, tt := range tests {
+		if actual := rawJson(canonical(s, tt.mode)); actual != tt.expected {
+			t.Fatalf("mode=%s expected=%s actual=%s", tt.mode, tt.expected, actual)
+		}
+	}
+}
+
+func TestJsonArrayModes(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/arrays/working.json",
+		MasterPath:  "test/arrays/master.json",
+		ArrayModes: map[string]ArrayMode{
+			"flags":   ArrayMultiset,
+			"tags":    ArrayMultiset,
+			"regions": ArraySet,
+		},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// flags differ by count, tags and regions only by order and order is
+	// compared positionally
+	expected := []string{"flags", "order"}
+
+	if len(result.Different) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.Different)
+	}
+
+	for i := range expected {
+		if result.Different[i].Key != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], result.Different[i].Key)
+		}
+	}
+
+	// multisets report the count of each element that changed
+	counts := []ElementCount{{Element: "a", Master: 1, Working: 2}, {Element: "b", Master: 2, Working: 1}}
+	if actual := result.ElementChanges["flags"].Counts; !reflect.DeepEqual(counts, actual) {
+		t.Fatalf("expected=%+v actual=%+v", counts, actual)
+	}
+
+	if expected := "flags: a: 1 -> 2, b: 2 -> 1"; !strings.Contains(result.Diff(), expected) {
+		t.Fatalf("expected %s in %s", expected, result.Diff())
+	}
+
+	c.ArrayModes["flags"], c.ArrayModes["order"] = ArraySet, ArraySet
+
+	analyzer, err := newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if equal, _ := analyzer.equality(); !equal {
+		t.Fatal("arrays compared as sets should be equal")
+	}
+}
+
+func TestArraySetElementChanges(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/whitelist/working.json",
+		MasterPath:  "test/whitelist/master.json",
+		ArrayModes: map[string]ArrayMode{
+			"*": ArraySet,
+		},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Different) != 2 {
+		t.Fatalf("expected=2 actual=%+v", result.Different)
+	}
+
+	// arrays of objects have no element changes
+	if _, ok := result.ElementChanges["hosts"]; ok {
+		t.Fatalf("expected no element changes actual=%+v", result.ElementChanges["hosts"])
+	}
+
+	whitelist := result.ElementChanges["whitelist"]
+	if len(whitelist.Added) != 1 || whitelist.Added[0] != "10.0.0.4" {
+		t.Fatalf("expected added=[10.0.0.4] actual=%+v", whitelist.Added)
+	}
+
+	if len(whitelist.Removed) != 1 || whitelist.Removed[0] != "10.0.0.2" {
+		t.Fatalf("expected removed=[10.0.0.2] actual=%+v", whitelist.Removed)
+	}
+
+	expected := "whitelist: added [10.0.0.4], removed [10.0.0.2]"
+	if !strings.Contains(result.Diff(), expected) {
+		t.Fatalf("expected %s in %s", expected, result.Diff())
+	}
+}
@@ -1,6 +1,7 @@
 package cfg
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 )
@@ -8,28 +9,40 @@ import (
 // bash holds data for connecting to an external host via bash
 type bash struct {
 	hostAlias string
+	proxyJump string
 }
 
-// newBash returns a new bash
-func newBash(host string) *bash {
+// newBash returns a new bash. proxyJump, if given, is the jump host the
+// connection is made through
+func newBash(host, proxyJump string) *bash {
 	return &bash{
 		hostAlias: host,
+		proxyJump: proxyJump,
 	}
 }
 
 // ssh runs a ssh command
-func (b bash) ssh() error {
-	_, err := b.command(fmt.Sprintf("ssh %s", b.hostAlias))
+func (b bash) ssh(ctx context.Context) error {
+	_, err := b.command(ctx, fmt.Sprintf("ssh%s %s", b.options(), b.hostAlias))
 	return err
 }
 
 // scp runs a scp (secure copy) command
-func (b bash) scp(path string) ([]byte, error) {
-	return b.command(
-		fmt.Sprintf("scp %s:%s /dev/stdout", b.hostAlias, path))
+func (b bash) scp(ctx context.Context, path string) ([]byte, error) {
+	return b.command(ctx,
+		fmt.Sprintf("scp%s %s:%s /dev/stdout", b.options(), b.hostAlias, path))
 }
 
-// command is the executable command
-func (b bash) command(cmd string) ([]byte, error) {
-	return exec.Command("/bin/bash", "-c", cmd).Output()
+// options returns the flags shared by ssh and scp
+func (b bash) options() string {
+	if b.proxyJump == "" {
+		return ""
+	}
+
+	return " -J " + b.proxyJump
+}
+
+// command is the executable command, killed if ctx is done first
+func (b bash) command(ctx context.Context, cmd string) ([]byte, error) {
+	return exec.CommandContext(ctx, "/bin/bash", "-c", cmd).Output()
 }
@@ -4,9 +4,21 @@ import "testing"
 
 func TestNewBash(t *testing.T) {
 	testHost := "test-host"
-	bash := newBash(testHost)
+	bash := newBash(testHost, "")
 
 	if bash.hostAlias != testHost {
 		t.Fatalf("expected=%s actual=%s", testHost, bash.hostAlias)
 	}
+
+	if bash.options() != "" {
+		t.Fatalf("expected no options actual=%s", bash.options())
+	}
+}
+
+func TestBashProxyJump(t *testing.T) {
+	bash := newBash("test-host", "bastion")
+
+	if expected := " -J bastion"; bash.options() != expected {
+		t.Fatalf("expected=%s actual=%s", expected, bash.options())
+	}
 }
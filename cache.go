@@ -0,0 +1,123 @@
+package cfg
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+)
+
+// Analyzer scans working files against a master, reusing the parsed and
+// flattened master between scans for as long as its content is unchanged.
+// it is safe for concurrent use
+type Analyzer struct {
+	config Config
+	cache  *masterCache
+}
+
+// NewAnalyzer returns an Analyzer for the config. Config.WorkingPath is
+// replaced by the path given to each Scan
+func NewAnalyzer(c Config) *Analyzer {
+	return &Analyzer{config: c, cache: newMasterCache()}
+}
+
+// Scan will scan the working file against the Analyzer's master, as Scan
+// would, returning a Result. the master is read on every call, but only
+// parsed again if its content has changed
+func (a *Analyzer) Scan(workingPath string) (*Result, error) {
+	return a.ScanContext(context.Background(), workingPath)
+}
+
+// ScanContext is Scan, abandoning the fetch of a remote master over ssh or
+// http(s) if ctx is done first
+func (a *Analyzer) ScanContext(ctx context.Context, workingPath string) (*Result, error) {
+	c := a.config
+	c.WorkingPath = workingPath
+
+	base, err := newAnalyzerContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	base.cache = a.cache
+
+	r, err := base.scan(base.config.format())
+	if err != nil {
+		return nil, err
+	}
+
+	return r, base.config.strict(r)
+}
+
+// masterCache holds prepared master files keyed by the sha256 of their
+// content. the cached values are shared between scans and must not be
+// modified
+type masterCache struct {
+	mu    sync.Mutex
+	jsons map[[sha256.Size]byte]cachedJson
+	envs  map[[sha256.Size]byte]parsedEnv
+}
+
+// cachedJson is a prepared json master, whether it holds Config.RootPath and
+// the files its references were resolved from
+type cachedJson struct {
+	master jsoncfg
+	found  bool
+	refs   refFiles
+}
+
+// newMasterCache returns an empty masterCache
+func newMasterCache() *masterCache {
+	return &masterCache{
+		jsons: map[[sha256.Size]byte]cachedJson{},
+		envs:  map[[sha256.Size]byte]parsedEnv{},
+	}
+}
+
+// json returns the prepared json master for the content b, calling prepare
+// if it isn't cached or a file its references were resolved from, stored by
+// prepare in the refFiles it's given, has changed. errors aren't cached
+func (m *masterCache) json(b []byte, prepare func(refFiles) (jsoncfg, bool, error)) (jsoncfg, bool, error) {
+	key := sha256.Sum256(b)
+
+	m.mu.Lock()
+	cached, ok := m.jsons[key]
+	m.mu.Unlock()
+
+	if ok && !cached.refs.changed() {
+		return cached.master, cached.found, nil
+	}
+
+	refs := refFiles{}
+	master, found, err := prepare(refs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	m.mu.Lock()
+	m.jsons[key] = cachedJson{master: master, found: found, refs: refs}
+	m.mu.Unlock()
+
+	return master, found, nil
+}
+
+// env returns the parsed env master for the content b, calling prepare if
+// it isn't cached
+func (m *masterCache) env(b []byte, prepare func() parsedEnv) parsedEnv {
+	key := sha256.Sum256(b)
+
+	m.mu.Lock()
+	cached, ok := m.envs[key]
+	m.mu.Unlock()
+
+	if ok {
+		return cached
+	}
+
+	cached = prepare()
+
+	m.mu.Lock()
+	m.envs[key] = cached
+	m.mu.Unlock()
+
+	return cached
+}
@@ -0,0 +1,218 @@
+package cfg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAnalyzerScan(t *testing.T) {
+	tests := []struct {
+		working, master string
+	}{
+		{"test/a.env", "test/b.env"},
+		{"test/r.env", "test/b.env"},
+		{"test/a.json", "test/b.json"},
+		{"test/c.json", "test/b.json"},
+	}
+
+	analyzers := map[string]*Analyzer{}
+
+	for _, tt := range tests {
+		expected, err := Scan(Config{WorkingPath: tt.working, MasterPath: tt.master})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, ok := analyzers[tt.master]
+		if !ok {
+			a = NewAnalyzer(Config{MasterPath: tt.master})
+			analyzers[tt.master] = a
+		}
+
+		// the second scan is served from the cache
+		for i := 0; i < 2; i++ {
+			actual, err := a.Scan(tt.working)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// json keys are reported in map order
+			actual.ScannedAt, actual.Duration = expected.ScannedAt, expected.Duration
+			for _, r := range []*Result{expected, actual} {
+				sort.Strings(r.Missing)
+				sort.Strings(r.MissingWithDefault)
+				sort.Strings(r.MissingRequired)
+				sort.Strings(r.Extra)
+			}
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("working=%s expected=%+v actual=%+v", tt.working, *expected, *actual)
+			}
+		}
+	}
+}
+
+func TestAnalyzerMasterChanged(t *testing.T) {
+	dir := t.TempDir()
+	working := filepath.Join(dir, "working.json")
+	master := filepath.Join(dir, "master.json")
+
+	if err := ioutil.WriteFile(working, []byte(`{"a": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAnalyzer(Config{MasterPath: master})
+
+	for _, tt := range []struct {
+		master  string
+		missing []string
+	}{
+		{`{"a": 1, "b": 2}`, []string{"b"}},
+		{`{"a": 1, "b": 2, "c": 3}`, []string{"b", "c"}},
+	} {
+		if err := ioutil.WriteFile(master, []byte(tt.master), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := a.Scan(working)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Strings(r.Missing)
+		if !reflect.DeepEqual(r.Missing, tt.missing) {
+			t.Fatalf("expected=%v actual=%v", tt.missing, r.Missing)
+		}
+	}
+}
+
+func TestAnalyzerRefChanged(t *testing.T) {
+	dir := t.TempDir()
+	working := filepath.Join(dir, "working.json")
+	master := filepath.Join(dir, "master.json")
+	db := filepath.Join(dir, "db.json")
+
+	if err := ioutil.WriteFile(working, []byte(`{"db": {"host": "a"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(master, []byte(`{"db": {"$ref": "db.json"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAnalyzer(Config{MasterPath: master, ResolveRefs: true})
+
+	// the master is unchanged, but not the file it references
+	for _, tt := range []struct {
+		db, missing string
+	}{
+		{`{"host": "a"}`, ""},
+		{`{"host": "a", "port": 1}`, "db.port"},
+	} {
+		if err := ioutil.WriteFile(db, []byte(tt.db), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := a.Scan(working)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := strings.Join(r.Missing, ","); actual != tt.missing {
+			t.Fatalf("expected=%v actual=%v", tt.missing, r.Missing)
+		}
+	}
+}
+
+func TestAnalyzerConcurrentScans(t *testing.T) {
+	a := NewAnalyzer(Config{MasterPath: "test/b.json"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := a.Scan("test/a.json"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// manyWorkings writes a large json master and 100 working files, each
+// lacking one of its services and changing the port of another
+func manyWorkings(b *testing.B) (master string, workings []string) {
+	dir := b.TempDir()
+
+	services := func(skip, changed int) []byte {
+		var m strings.Builder
+		m.WriteString("{")
+		for i := 0; i < 2000; i++ {
+			if i == skip {
+				continue
+			}
+			if m.Len() > 1 {
+				m.WriteString(",")
+			}
+			port := i
+			if i == changed {
+				port = -i
+			}
+			fmt.Fprintf(&m, `"service_%d": {"host": "localhost", "port": %d, "tags": ["a", "b"]}`, i, port)
+		}
+		m.WriteString("}")
+		return []byte(m.String())
+	}
+
+	master = filepath.Join(dir, "master.json")
+	if err := ioutil.WriteFile(master, services(-1, -1), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		working := filepath.Join(dir, fmt.Sprintf("working_%d.json", i))
+		if err := ioutil.WriteFile(working, services(i, i+1), 0644); err != nil {
+			b.Fatal(err)
+		}
+		workings = append(workings, working)
+	}
+
+	return master, workings
+}
+
+func BenchmarkScanManyWorkings(b *testing.B) {
+	master, workings := manyWorkings(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, working := range workings {
+			if _, err := Scan(Config{WorkingPath: working, MasterPath: master}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkAnalyzerScanManyWorkings(b *testing.B) {
+	master, workings := manyWorkings(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		a := NewAnalyzer(Config{MasterPath: master})
+		for _, working := range workings {
+			if _, err := a.Scan(working); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Command cfganalyze compares a working config file against a master file,
+// exiting non-zero when they have drifted apart.
+//
+// Usage:
+//
+//	cfganalyze [scan|diff|watch] -working .env -master .env.example [-format env] [-host alias] [-output json|yaml] [-strict]
+//
+// scan, the default, reports the drift between the files. diff lists every
+// key that differs, prefixed with - when missing from the working file, + when
+// extra and ~ when its value changed, and fails on any difference. watch scans
+// every -interval, reporting whenever the result changes, until interrupted.
+//
+// Exit codes are 0 when the files are in sync, 1 when keys are missing or
+// forbidden (or differ, with -strict or diff) and 2 when the scan could not
+// run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adamjace/cfg"
+)
+
+const (
+	exitOk    = 0
+	exitDrift = 1
+	exitError = 2
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// headers collects repeated -header "Name: value" flags
+type headers map[string]string
+
+func (h headers) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headers) Set(value string) error {
+	name, v, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected \"Name: value\" got %q", value)
+	}
+
+	h[strings.TrimSpace(name)] = strings.TrimSpace(v)
+	return nil
+}
+
+// run parses the subcommand and command line args, scans and reports,
+// returning the exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	command := "scan"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "scan", "diff", "watch":
+	default:
+		fmt.Fprintf(stderr, "cfganalyze: unknown command %s. expected scan, diff or watch\n", command)
+		return exitError
+	}
+
+	flags := flag.NewFlagSet("cfganalyze "+command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var (
+		working  = flags.String("working", "", "path of the working config file")
+		master   = flags.String("master", "", "path or url of the master config file")
+		format   = flags.String("format", "", "format of the config files (env, json, yaml, toml, ini, properties, xml, hcl). detected from -working by default")
+		host     = flags.String("host", "", "ssh host alias to read the master file from")
+		jump     = flags.String("jump", "", "ssh jump host to reach -host through")
+		sshHost  = flags.String("ssh-host", "", "host to read the master file from over sftp, without the local ssh binary")
+		sshPort  = flags.Int("ssh-port", 0, "port of -ssh-host. 22 by default")
+		sshUser  = flags.String("ssh-user", "", "user connecting to -ssh-host. the current user by default")
+		sshKey   = flags.String("ssh-key", "", "private key for -ssh-host. the ssh-agent is used by default")
+		known    = flags.String("known-hosts", "", "known_hosts file verifying -ssh-host. ~/.ssh/known_hosts by default")
+		inside   = flags.String("container", "", "docker container to read the working file from")
+		proxy    = flags.String("proxy", "", "proxy url for fetching a master over http(s). HTTP_PROXY is used by default")
+		timeout  = flags.Duration("timeout", 30*time.Second, "timeout for fetching a master over http(s)")
+		output   = flags.String("output", "text", "output format (text, json, yaml)")
+		asJson   = flags.Bool("json", false, "output the result as json, the same as -output json")
+		export   = flags.Bool("export", false, "output missing keys as export statements using their master values, for eval")
+		strict   = flags.Bool("strict", false, "also fail when keys are extra or values differ")
+		across   = flags.Bool("compare-formats", false, "allow -working and -master to be different formats, each detected from its extension")
+		interval = flags.Duration("interval", 2*time.Second, "time between scans with watch")
+	)
+
+	header := headers{}
+	flags.Var(header, "header", "header set when fetching a master over http(s), as \"Name: value\". may be repeated")
+
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	if *working == "" || *master == "" {
+		fmt.Fprintln(stderr, "cfganalyze: -working and -master are required")
+		flags.Usage()
+		return exitError
+	}
+
+	if *asJson {
+		*output = "json"
+	}
+
+	if *output != "text" && *output != "json" && *output != "yaml" {
+		fmt.Fprintf(stderr, "cfganalyze: unsupported output %s. expected text, json or yaml\n", *output)
+		return exitError
+	}
+
+	if command == "watch" && *interval <= 0 {
+		fmt.Fprintln(stderr, "cfganalyze: -interval must be positive")
+		return exitError
+	}
+
+	c := cfg.Config{
+		WorkingPath:    *working,
+		MasterPath:     *master,
+		HostAlias:      *host,
+		ProxyJump:      *jump,
+		SSHHost:        *sshHost,
+		SSHPort:        *sshPort,
+		SSHUser:        *sshUser,
+		SSHKey:         *sshKey,
+		SSHKnownHosts:  *known,
+		Container:      *inside,
+		Proxy:          *proxy,
+		Format:         cfg.Format(*format),
+		HTTPTimeout:    *timeout,
+		HTTPHeaders:    header,
+		CompareFormats: *across,
+	}
+
+	if *export {
+		if err := cfg.PrintExports(c, stdout); err != nil {
+			fmt.Fprintf(stderr, "cfganalyze: %s\n", err)
+			return exitError
+		}
+		return exitOk
+	}
+
+	if command == "watch" {
+		return watch(ctx, c, *interval, *output, *strict, stdout, stderr)
+	}
+
+	result, err := cfg.ScanContext(ctx, c)
+	if err != nil {
+		fmt.Fprintf(stderr, "cfganalyze: %s\n", err)
+		return exitError
+	}
+
+	write := report
+	if command == "diff" {
+		write = diff
+	}
+
+	if err := write(stdout, result, *output); err != nil {
+		fmt.Fprintf(stderr, "cfganalyze: %s\n", err)
+		return exitError
+	}
+
+	if drifted(result, *strict || command == "diff") {
+		return exitDrift
+	}
+
+	return exitOk
+}
+
+// drifted determines if the result fails a CI gate: keys are missing or
+// forbidden, or with strict, extra or different
+func drifted(r *cfg.Result, strict bool) bool {
+	drift := len(r.Missing) > 0 || len(r.Forbidden) > 0
+	if strict {
+		drift = drift || len(r.Extra) > 0 || len(r.Different) > 0
+	}
+
+	return drift
+}
+
+// watch scans every interval until ctx is done, writing the result whenever
+// it changes and errors as they occur, then returns the exit code of the
+// last scan. with strict, extra and different keys are drift too
+func watch(ctx context.Context, c cfg.Config, interval time.Duration, output string, strict bool, stdout, stderr io.Writer) int {
+	last, code := "", exitOk
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := cfg.ScanContext(ctx, c)
+
+		switch {
+		case ctx.Err() != nil:
+			return code
+
+		case err != nil:
+			if last != err.Error() {
+				fmt.Fprintf(stderr, "cfganalyze: %s\n", err)
+			}
+			last, code = err.Error(), exitError
+
+		case last != signature(result):
+			fmt.Fprintf(stdout, "%s %s\n", result.ScannedAt.Format(time.RFC3339), summary(result))
+			if err := report(stdout, result, output); err != nil {
+				fmt.Fprintf(stderr, "cfganalyze: %s\n", err)
+			}
+
+			last, code = signature(result), exitOk
+			if drifted(result, strict) {
+				code = exitDrift
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return code
+		case <-ticker.C:
+		}
+	}
+}
+
+// signature identifies the drift of a result, regardless of the order its
+// keys were found in
+func signature(r *cfg.Result) string {
+	lines := strings.Split(r.Diff(), "\n")
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// summary describes a result in one line
+func summary(r *cfg.Result) string {
+	if !drifted(r, true) {
+		return "in sync"
+	}
+
+	return fmt.Sprintf("%d missing, %d extra, %d different", len(r.Missing), len(r.Extra), len(r.Different))
+}
+
+// report writes a plain text summary or the json or yaml of the result
+func report(w io.Writer, r *cfg.Result, output string) error {
+	return cfg.WriteResult(w, r, cfg.OutputFormat(output))
+}
+
+// diff writes a line per differing key, prefixed with - when missing from
+// the working file, + when extra and ~ when its value changed, or the json or
+// yaml of the result
+func diff(w io.Writer, r *cfg.Result, output string) error {
+	if output != "text" {
+		return report(w, r, output)
+	}
+
+	var b strings.Builder
+
+	for _, key := range sorted(r.Missing) {
+		fmt.Fprintf(&b, "- %s\n", key)
+	}
+
+	for _, key := range sorted(r.Extra) {
+		fmt.Fprintf(&b, "+ %s\n", key)
+	}
+
+	for _, d := range r.Different {
+		fmt.Fprintf(&b, "~ %s: %s -> %s\n", d.Key, d.Master, d.Working)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sorted returns a sorted copy of keys
+func sorted(keys []string) []string {
+	keys = append([]string{}, keys...)
+	sort.Strings(keys)
+
+	return keys
+}
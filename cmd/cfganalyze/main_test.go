@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adamjace/cfg"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"-working", "../../test/a.env", "-master", "../../test/b.env"}, exitDrift},
+		{[]string{"-working", "../../test/c.env", "-master", "../../test/d.env"}, exitOk},
+		{[]string{"-working", "../../test/c.env", "-master", "../../test/d.env", "-strict"}, exitDrift},
+		{[]string{"-working", "../../test/c.env", "-master", "../../test/missing.env"}, exitError},
+		{[]string{"-working", "../../test/c.env"}, exitError},
+		{[]string{"-working", "../../test/c.env", "-master", "../../test/d.env", "-header", "Authorization"}, exitError},
+		{[]string{"-working", "../../test/c.env", "-master", "../../test/d.env", "-header", "Authorization: Bearer token"}, exitOk},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if actual := run(context.Background(), tt.args, &stdout, &stderr); actual != tt.expected {
+			t.Fatalf("args=%v expected=%d actual=%d stderr=%s", tt.args, tt.expected, actual, stderr.String())
+		}
+	}
+}
+
+func TestRunJson(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"-working", "../../test/a.json", "-master", "../../test/b.json", "-json"}
+	if code := run(context.Background(), args, &stdout, &stderr); code != exitDrift {
+		t.Fatalf("expected=%d actual=%d", exitDrift, code)
+	}
+
+	result := cfg.Result{}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 2 {
+		t.Fatalf("expected=%d actual=%d", 2, len(result.Missing))
+	}
+}
+
+func TestRunExport(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"-working", "../../test/i.env", "-master", "../../test/j.env", "-export"}
+	if code := run(context.Background(), args, &stdout, &stderr); code != exitOk {
+		t.Fatalf("expected=%d actual=%d stderr=%s", exitOk, code, stderr.String())
+	}
+
+	if expected := "export NAME='cfg, \"the\" analyzer'\n"; stdout.String() != expected {
+		t.Fatalf("expected=%s actual=%s", expected, stdout.String())
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"scan", "-working", "../../test/a.env", "-master", "../../test/b.env"}, exitDrift},
+		{[]string{"scan", "-working", "../../test/c.env", "-master", "../../test/d.env"}, exitOk},
+		{[]string{"diff", "-working", "../../test/c.env", "-master", "../../test/d.env"}, exitDrift},
+		{[]string{"scan", "-working", "../../test/c.env", "-master", "../../test/d.env", "-output", "xml"}, exitError},
+		{[]string{"watch", "-working", "../../test/c.env", "-master", "../../test/d.env", "-interval", "0s"}, exitError},
+		{[]string{"merge", "-working", "../../test/c.env", "-master", "../../test/d.env"}, exitError},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if actual := run(context.Background(), tt.args, &stdout, &stderr); actual != tt.expected {
+			t.Fatalf("args=%v expected=%d actual=%d stderr=%s", tt.args, tt.expected, actual, stderr.String())
+		}
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"diff", "-working", "../../test/a.env", "-master", "../../test/b.env"}
+	if code := run(context.Background(), args, &stdout, &stderr); code != exitDrift {
+		t.Fatalf("expected=%d actual=%d stderr=%s", exitDrift, code, stderr.String())
+	}
+
+	if expected := "- DRINK\n- FOOD\n- LANG\n"; stdout.String() != expected {
+		t.Fatalf("expected=%q actual=%q", expected, stdout.String())
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	args := []string{"watch", "-working", "../../test/a.env", "-master", "../../test/b.env", "-interval", "10ms"}
+	if code := run(ctx, args, &stdout, &stderr); code != exitDrift {
+		t.Fatalf("expected=%d actual=%d stderr=%s", exitDrift, code, stderr.String())
+	}
+
+	// the drift is only reported once while it's unchanged
+	if count := strings.Count(stdout.String(), "3 missing"); count != 1 {
+		t.Fatalf("expected=%d actual=%d stdout=%s", 1, count, stdout.String())
+	}
+}
+
+func TestRunWatchStrict(t *testing.T) {
+	// c.env and d.env only differ in the value of SPORT
+	args := []string{"watch", "-working", "../../test/c.env", "-master", "../../test/d.env", "-interval", "10ms"}
+
+	for _, tt := range []struct {
+		args     []string
+		expected int
+	}{
+		{args, exitOk},
+		{append(args, "-strict"), exitDrift},
+	} {
+		var stdout, stderr bytes.Buffer
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		code := run(ctx, tt.args, &stdout, &stderr)
+		cancel()
+
+		if code != tt.expected {
+			t.Fatalf("args=%v expected=%d actual=%d stderr=%s", tt.args, tt.expected, code, stderr.String())
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		result   *cfg.Result
+		expected string
+	}{
+		{&cfg.Result{WorkingPath: ".env", MasterPath: ".env.example"}, "in sync"},
+		{&cfg.Result{Missing: []string{"FOOD"}, Extra: []string{"SPORT"}}, "1 missing, 1 extra, 0 different"},
+		{&cfg.Result{Different: []cfg.DiffEntry{{Key: "FRUIT"}}}, "0 missing, 0 extra, 1 different"},
+	}
+
+	for _, tt := range tests {
+		if actual := summary(tt.result); actual != tt.expected {
+			t.Fatalf("expected=%s actual=%s", tt.expected, actual)
+		}
+	}
+}
+
+func TestRunYaml(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"-working", "../../test/a.env", "-master", "../../test/b.env", "-output", "yaml"}
+	if code := run(context.Background(), args, &stdout, &stderr); code != exitDrift {
+		t.Fatalf("expected=%d actual=%d stderr=%s", exitDrift, code, stderr.String())
+	}
+
+	if expected := "missing:\n    - FOOD\n    - LANG\n    - DRINK\n"; !strings.Contains(stdout.String(), expected) {
+		t.Fatalf("expected=%s actual=%s", expected, stdout.String())
+	}
+}
@@ -1,8 +1,595 @@
 package cfg
 
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// Perspective is the file the Print functions report from
+type Perspective string
+
+const (
+	// PerspectiveMaster reports what the working file is missing compared to
+	// the master, the default
+	PerspectiveMaster Perspective = "master"
+
+	// PerspectiveWorking leads with what the working file has that the
+	// master doesn't, then what it lacks
+	PerspectiveWorking Perspective = "working"
+)
+
 // Config holds the required configuration for the package
 type Config struct {
 	WorkingPath string
 	MasterPath  string
 	HostAlias   string
+
+	// Container is the name or id of a running docker container to read the
+	// working file from, via docker exec, rather than the local filesystem
+	Container string
+
+	// MaxConnectionsPerSecond limits how quickly new ssh connections are
+	// established across all concurrent scans, e.g. when scanning a fleet of
+	// hosts through a bastion. Zero means unlimited
+	MaxConnectionsPerSecond float64
+
+	// Format of the working and master files. Determined by the working
+	// file's extension when empty
+	Format Format
+
+	// DecryptFunc is called with the raw bytes of both the working and master
+	// files before they are parsed, e.g. to decrypt SOPS or age encrypted
+	// configs in memory. Bytes are used as is when nil
+	DecryptFunc func([]byte) ([]byte, error)
+
+	// EnvSeparator un-flattens the keys of an env working file by this
+	// separator (e.g. "_") so it can be compared against a nested json master.
+	// DB_HOST=x becomes {"db": {"host": "x"}}. keys are lowercased
+	EnvSeparator string
+
+	// CompareFormats allows the working and master to be different formats,
+	// e.g. a deployed .env against a config.example.json. each file is read
+	// in the format of its own extension and compared as nested keys, a flat
+	// env file un-flattened by KeyConvention
+	CompareFormats bool
+
+	// KeyConvention maps the keys of a flat env file to nested paths when
+	// CompareFormats is set. Defaults to KeyUpperSnake, DB_HOST as db.host.
+	// EnvSeparator, when set, is used instead
+	KeyConvention KeyConvention
+
+	// MasterPaths holds several master files to merge into one master and
+	// compare against, used instead of MasterPath when set. later files
+	// override the keys of earlier ones and the file each key came from is
+	// reported. they are read in the same way as MasterPath, apart from urls
+	MasterPaths []string
+
+	// MasterGlob is a glob pattern (e.g. "backups/config.*.json") of local
+	// master files, used instead of MasterPath when set. the latest match by
+	// name is the master, so timestamped names must sort chronologically
+	MasterGlob string
+
+	// AllowSamePath permits the working and master to be the same file, for
+	// a deliberate self-check. otherwise it's an error, as the files would
+	// always be reported in sync
+	AllowSamePath bool
+
+	// DefaultsPath is a local file of defaults, the lowest tier beneath the
+	// working and master files. keys missing from the working file that it
+	// provides are reported as using their default rather than missing, and
+	// keys only in the working file that it knows of aren't extra
+	DefaultsPath string
+
+	// MasterFromEnv names an environment variable whose value is the master
+	// file's contents, used instead of MasterPath when set
+	MasterFromEnv string
+
+	// HTTPTimeout bounds the time spent fetching a master file over http(s).
+	// A default of 30 seconds is used when zero
+	HTTPTimeout time.Duration
+
+	// Proxy is the url of the proxy, e.g. "socks5://localhost:1080", that a
+	// master file is fetched over http(s) through. Defaults to the proxy set
+	// by the HTTP_PROXY and HTTPS_PROXY environment variables
+	Proxy string
+
+	// ProxyJump is the jump host, as given to ssh -J, that connections to
+	// HostAlias are made through
+	ProxyJump string
+
+	// SSHHost is the host the master file is read from over sftp, using a
+	// native ssh connection rather than HostAlias and the local ssh and scp
+	// binaries. SSHPort defaults to 22 and SSHUser to the current user
+	SSHHost string
+	SSHPort int
+	SSHUser string
+
+	// SSHKey is the private key authenticating SSHUser, either a file path or
+	// PEM encoded, decrypted with SSHKeyPassphrase if it's protected. the keys
+	// of the running ssh-agent are used when empty
+	SSHKey           string
+	SSHKeyPassphrase string
+
+	// SSHKnownHosts is the known_hosts file SSHHost's key is verified against.
+	// Defaults to ~/.ssh/known_hosts. an unknown or changed host key is an
+	// error
+	SSHKnownHosts string
+
+	// SSHTimeout bounds the time spent establishing the ssh connection to
+	// SSHHost. A default of 30 seconds is used when zero
+	SSHTimeout time.Duration
+
+	// HTTPHeaders are set on the request fetching a master over http(s), e.g.
+	// {"Authorization": "Bearer ..."} for a config service requiring auth
+	HTTPHeaders map[string]string
+
+	// MaxBytes caps the size of a master file fetched over http(s). Zero
+	// means no limit
+	MaxBytes int64
+
+	// ClientCert and ClientKey are the client certificate and key presented
+	// when fetching a master over https from a server requiring mutual TLS.
+	// CACert is the CA trusted to sign the server's certificate, instead of
+	// the system trust store. each is either a file path or PEM encoded
+	ClientCert string
+	ClientKey  string
+	CACert     string
+
+	// MasterGitRef reads the master file as committed at a git ref (e.g.
+	// "main") via git show, rather than from disk
+	MasterGitRef string
+
+	// MasterGitPath is the path of the master file within MasterGitRef.
+	// Defaults to MasterPath
+	MasterGitPath string
+
+	// KeyTransform is applied to every key of both files before comparing, to
+	// reconcile naming conventions, e.g. stripping an "APP_" prefix or
+	// converting kebab-case to snake_case. keys are reported as transformed.
+	// Keys are used as is when nil
+	KeyTransform func(string) string
+
+	// EnvTokenMap maps environment tokens found in values to their
+	// counterpart, e.g. {"staging": "prod"}. Each key is substituted with its
+	// value on both sides before comparing, so values that differ only by
+	// environment name are treated as equal
+	EnvTokenMap map[string]string
+
+	// ExpandEnv interpolates ${KEY} and $KEY references within env values
+	// using the other keys of the same file before comparing. references to
+	// undefined keys are reported as dangling
+	ExpandEnv bool
+
+	// CheckOrder reports keys that exist in both env files but at different
+	// positions, for configs that are consumed positionally
+	CheckOrder bool
+
+	// RootPath is a dotted path (e.g. "logging") to the subtree of both json
+	// files to compare, rather than the whole files. keys are reported
+	// relative to it. a subtree only in the master is missing in full
+	RootPath string
+
+	// OptionalSections holds the paths, or glob patterns of paths, of json
+	// objects (e.g. "metrics") that may be absent from the working file. an
+	// absent section is noted once rather than reported as missing, while a
+	// partial section is compared key by key
+	OptionalSections []string
+
+	// KeySeparator joins the keys of nested json objects into the paths that
+	// are reported and configured, defaulting to ".". a key that contains the
+	// separator or a bracket is bracketed, e.g. [a.b], so that paths remain
+	// unambiguous, with any ] or \ within it escaped by a \, e.g. [x] is
+	// [[x\]]. it is matched by patterns written the same way, e.g. "[a.b].*"
+	KeySeparator string
+
+	// JSONOrdered reports keys that exist in both json files but at different
+	// positions within the same object, for configs where key order matters.
+	// nested keys are reported as dotted paths
+	JSONOrdered bool
+
+	// ResolveRefs inlines json references, objects of the form
+	// {"$ref": "other.json#/path"}, and yaml includes, values tagged
+	// "!include other.yaml", before comparing so that the effective config is
+	// compared. referenced files are read relative to the file referencing
+	// them, so both files must be local
+	ResolveRefs bool
+
+	// WorkingTransform and MasterTransform are jq expressions applied to the
+	// parsed json working and master files before comparing, to normalize
+	// files that are structured differently, e.g. "{db: .database}". each
+	// must produce a single object
+	WorkingTransform string
+	MasterTransform  string
+
+	// CoerceScalars compares scalar values by their canonical text rather than
+	// their type, so "true", "yes" and true are equal, as are "123" and 123.
+	// useful when comparing files of different formats
+	CoerceScalars bool
+
+	// StrictTypes reports json values that compare equal only because a
+	// string is compared to a number, bool or null by its text, as happens
+	// when an env working file is compared to a json master
+	StrictTypes bool
+
+	// CompareTypes reports keys whose json type, string, number, bool, null,
+	// object or array, differs between the files, e.g. a port quoted in one
+	// environment. the working file must be json, yaml or toml
+	CompareTypes bool
+
+	// CompareComments reports env keys whose leading comments, the "#" lines
+	// directly above them, differ between the files even if their values
+	// don't, e.g. when the master's guidance for a key is updated. only env
+	// files are supported, as properties and yaml files are converted to json
+	// before comparing, which drops their comments
+	CompareComments bool
+
+	// IgnoreExtra skips reporting the keys the working file has that the
+	// master doesn't, leaving Result.Extra empty
+	IgnoreExtra bool
+
+	// FailFast stops a multi-file scan, e.g. ScanWorkings or ScanArchive, at
+	// the first file that can't be read or parsed, rather than recording its
+	// error and scanning the rest
+	FailFast bool
+
+	// StrictMode makes the Scan and Print functions return an ErrMissingKeys
+	// along with their outcome when the working file is missing keys, so a CI
+	// pipeline can fail without parsing the output
+	StrictMode bool
+
+	// ListValueKeys holds glob patterns (e.g. "ALLOWED_*") of keys whose values
+	// are order-independent lists. matching values are split by ListDelimiter
+	// and compared as sets
+	ListValueKeys []string
+
+	// ListDelimiter separates the elements of a list value. Defaults to ","
+	ListDelimiter string
+
+	// ArrayModes maps glob patterns of dotted json paths (e.g. "features.*")
+	// to how arrays at those paths are compared: positionally, the default,
+	// as a set or as a multiset
+	ArrayModes map[string]ArrayMode
+
+	// XmlLists holds glob patterns of dotted xml element paths (e.g.
+	// "configuration.appender") whose repeated elements are compared as a
+	// json array, per ArrayModes, rather than keyed by their index. a single
+	// matching element is an array of one
+	XmlLists []string
+
+	// Base64Keys holds glob patterns of keys whose values are base64 encoded.
+	// matching values are decoded before comparing, so encodings that differ
+	// only by padding or line wrapping are equal
+	Base64Keys []string
+
+	// Placeholders holds glob patterns of master values that stand in for a
+	// value the deployer must provide, e.g. "__CHANGEME__" or "<*>". a value
+	// equal to a pattern always matches it, so "[CHANGE ME]" is a literal
+	// placeholder rather than a character class. a key whose master value is
+	// a placeholder accepts any working value, though it is still reported if
+	// missing
+	Placeholders []string
+
+	// IgnoreValuePatterns holds regular expressions of volatile values, e.g.
+	// timestamps or build hashes. a difference is ignored when both the master
+	// and working values match the same pattern
+	IgnoreValuePatterns []string
+
+	// Ignore holds patterns of keys that are intentionally different, e.g.
+	// "secrets.*" or "AWS_*", which are left out of the comparison entirely.
+	// patterns are globs, or regular expressions when wrapped in slashes,
+	// e.g. "/^AWS_/". nested keys are matched as dotted paths, and ignoring a
+	// key ignores the keys nested within it
+	Ignore []string
+
+	// RedactPatterns holds words of keys, e.g. "password" or "token", whose
+	// values are replaced by ***** wherever they're printed or returned in a
+	// diff, so they don't leak into CI logs. a pattern matches the first or
+	// last words of a key or of any key it's nested in, ignoring case, so KEY
+	// matches API_KEY and apiKey but not MONKEY or PRIMARY_KEY_COLUMN.
+	// Defaults to PASSWORD, SECRET, TOKEN and KEY when nil, an empty slice
+	// redacting nothing. PrintExports isn't redacted, as its values are meant
+	// to be evaluated
+	RedactPatterns []string
+
+	// ForbiddenKeys holds glob patterns of keys that must not exist in the
+	// working file, e.g. deprecated settings. matching keys are reported as
+	// forbidden. nested json keys are matched as dotted paths
+	ForbiddenKeys []string
+
+	// PathStyle is how the paths of nested json keys are written, dotted
+	// paths joined by KeySeparator or RFC 6901 JSON Pointers such as
+	// /db/pool/max. Defaults to PathDotted
+	PathStyle PathStyle
+
+	// ResolveAll makes ResolveInteractive add every missing key with its
+	// master value when its input isn't a terminal, rather than failing
+	ResolveAll bool
+
+	// SnapshotDir is where SaveSnapshot stores snapshots of the working file.
+	// Defaults to a cfganalyze directory within the user's cache directory
+	SnapshotDir string
+
+	// MissingThreshold is the number of missing keys considered acceptable
+	// noise. the Print functions only warn about missing keys when there are
+	// more than this, noting them otherwise
+	MissingThreshold int
+
+	// DryRun makes every operation that writes files, ResolveInteractive and
+	// SaveSnapshot, print what it would write to DryRunOutput instead
+	DryRun bool
+
+	// DryRunOutput receives what would be written when DryRun is set.
+	// Defaults to os.Stdout
+	DryRunOutput io.Writer
+
+	// Grouped makes the Print functions bucket missing and different keys
+	// under their top level prefix, the part of the key before the first "_"
+	Grouped bool
+
+	// Perspective frames the output of the Print functions from the master
+	// or the working file. Defaults to PerspectiveMaster
+	Perspective Perspective
+
+	// OutputFormat is the format the Print functions write their report in,
+	// json or yaml writing the Result for other tools to parse. Defaults to
+	// OutputText
+	OutputFormat OutputFormat
+
+	// Logger receives structured logs of connection attempts, fetch durations,
+	// parse counts and errors. Nothing is logged when nil
+	Logger *slog.Logger
+}
+
+// format returns the configured Format, falling back to the working file's
+// extension. this is the format both files are compared in
+func (c Config) format() Format {
+	if c.Format != "" && c.Format != FormatDir {
+		return c.Format
+	}
+
+	masterPath := c.MasterPath
+	if len(c.MasterPaths) > 0 {
+		masterPath = c.MasterPaths[0]
+	} else if c.MasterGlob != "" {
+		masterPath = c.MasterGlob
+	}
+
+	working, master := formatOf(c.WorkingPath), formatOf(masterPath)
+
+	// a flat env working file is un-flattened to compare against a nested master
+	if c.EnvSeparator != "" && master.nested() {
+		return master
+	}
+
+	// files of different formats are compared as nested keys
+	if c.CompareFormats {
+		switch {
+		case working.nested():
+			return working
+		case master.nested():
+			return master
+		}
+	}
+
+	// a directory of secrets is compared in the format of the other side
+	if working == FormatDir {
+		if master == FormatDir {
+			return FormatEnv
+		}
+		return master
+	}
+
+	return working
+}
+
+// remoteHost determines if the master file is read from a remote host over
+// ssh
+func (c Config) remoteHost() bool {
+	return c.HostAlias != "" || c.SSHHost != ""
+}
+
+// Validate checks the Config for missing or mutually exclusive fields,
+// returning a descriptive error for the first problem found
+func (c Config) Validate() error {
+	if c.WorkingPath == "" {
+		return errors.New("invalid config. WorkingPath is required")
+	}
+
+	switch {
+	case c.MasterPath != "" && c.MasterFromEnv != "":
+		return errors.New("invalid config. MasterPath and MasterFromEnv are mutually exclusive")
+	case len(c.MasterPaths) > 0 && (c.MasterPath != "" || c.MasterFromEnv != ""):
+		return errors.New("invalid config. MasterPaths can't be used with MasterPath or MasterFromEnv")
+	case c.MasterGlob != "" && (c.MasterPath != "" || len(c.MasterPaths) > 0 || c.MasterFromEnv != ""):
+		return errors.New("invalid config. MasterGlob can't be used with MasterPath, MasterPaths or MasterFromEnv")
+	case c.MasterGlob != "" && (c.remoteHost() || c.MasterGitRef != ""):
+		return errors.New("invalid config. MasterGlob requires local master files")
+	case c.MasterPath == "" && c.MasterFromEnv == "" && c.MasterGitPath == "" && len(c.MasterPaths) == 0 && c.MasterGlob == "":
+		return errors.New("invalid config. one of MasterPath, MasterPaths, MasterGlob or MasterFromEnv is required")
+	case c.MasterGitPath != "" && c.MasterGitRef == "":
+		return errors.New("invalid config. MasterGitPath requires MasterGitRef")
+	}
+
+	if c.Format != "" && !c.Format.builtin() && c.Format.parser() == nil {
+		return fmt.Errorf("invalid config. unsupported format %s", c.Format)
+	}
+
+	switch c.PathStyle {
+	case "", PathDotted, PathJSONPointer:
+	default:
+		return fmt.Errorf("invalid config. unsupported path style %s", c.PathStyle)
+	}
+
+	switch c.Perspective {
+	case "", PerspectiveMaster, PerspectiveWorking:
+	default:
+		return fmt.Errorf("invalid config. unsupported perspective %s", c.Perspective)
+	}
+
+	switch c.OutputFormat {
+	case "", OutputText, OutputJson, OutputYaml:
+	default:
+		return fmt.Errorf("invalid config. unsupported output format %s", c.OutputFormat)
+	}
+
+	switch c.KeyConvention {
+	case "":
+	case KeyUpperSnake, KeyDotted:
+		if !c.CompareFormats {
+			return errors.New("invalid config. KeyConvention requires CompareFormats")
+		}
+	default:
+		return fmt.Errorf("invalid config. unsupported key convention %s", c.KeyConvention)
+	}
+
+	for pattern, mode := range c.ArrayModes {
+		switch mode {
+		case ArrayPositional, ArraySet, ArrayMultiset:
+		default:
+			return fmt.Errorf("invalid config. unsupported array mode %s for %s", mode, pattern)
+		}
+	}
+
+	if c.HostAlias != "" && c.SSHHost != "" {
+		return errors.New("invalid config. HostAlias and SSHHost are mutually exclusive")
+	}
+
+	if c.SSHHost == "" && (c.SSHPort != 0 || c.SSHUser != "" || c.SSHKey != "" || c.SSHKeyPassphrase != "" ||
+		c.SSHKnownHosts != "" || c.SSHTimeout != 0) {
+		return errors.New("invalid config. SSHPort, SSHUser, SSHKey, SSHKnownHosts and SSHTimeout require SSHHost")
+	}
+
+	if c.SSHPort < 0 || c.SSHPort > 65535 || c.SSHTimeout < 0 {
+		return errors.New("invalid config. SSHPort must be a valid port and SSHTimeout can't be negative")
+	}
+
+	if c.remoteHost() {
+		host := "HostAlias"
+		if c.SSHHost != "" {
+			host = "SSHHost"
+		}
+
+		switch {
+		case c.MasterFromEnv != "":
+			return fmt.Errorf("invalid config. %s can't be used with MasterFromEnv", host)
+		case c.MasterGitRef != "":
+			return fmt.Errorf("invalid config. %s can't be used with MasterGitRef", host)
+		case isUrl(c.MasterPath):
+			return fmt.Errorf("invalid config. %s can't be used with a url MasterPath", host)
+		case c.Format == FormatDir:
+			return errors.New("invalid config. a remote master can't be a directory")
+		}
+	}
+
+	for _, path := range c.MasterPaths {
+		if isUrl(path) {
+			return fmt.Errorf("invalid config. MasterPaths can't hold a url %s", path)
+		}
+	}
+
+	if c.ProxyJump != "" && c.HostAlias == "" {
+		return errors.New("invalid config. ProxyJump requires HostAlias")
+	}
+
+	if c.MasterGitRef != "" && isUrl(c.MasterPath) {
+		return errors.New("invalid config. MasterGitRef can't be used with a url MasterPath")
+	}
+
+	if strings.HasPrefix(c.MasterGitRef, "-") {
+		return errors.New("invalid config. MasterGitRef can't begin with -")
+	}
+
+	if c.ResolveRefs && (c.remoteHost() || c.MasterFromEnv != "" || c.MasterGitRef != "" ||
+		isUrl(c.MasterPath) || len(c.MasterPaths) > 0 || c.Container != "") {
+		return errors.New("invalid config. ResolveRefs requires a local working and master file")
+	}
+
+	if (c.WorkingTransform != "" || c.MasterTransform != "") && !c.format().nested() {
+		return errors.New("invalid config. WorkingTransform and MasterTransform require json or yaml files")
+	}
+
+	if c.CompareTypes && (!c.format().nested() || formatOf(c.WorkingPath) == FormatEnv) {
+		return errors.New("invalid config. CompareTypes requires json, yaml or toml files")
+	}
+
+	if c.CompareComments && c.format() != FormatEnv {
+		return errors.New("invalid config. CompareComments requires env files")
+	}
+
+	for _, expression := range []string{c.WorkingTransform, c.MasterTransform} {
+		if _, err := gojq.Parse(expression); expression != "" && err != nil {
+			return fmt.Errorf("invalid config. could not parse transform %s. %s", expression, err)
+		}
+	}
+
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return errors.New("invalid config. ClientCert and ClientKey must be set together")
+	}
+
+	if c.MaxConnectionsPerSecond < 0 || c.HTTPTimeout < 0 || c.MaxBytes < 0 {
+		return errors.New("invalid config. MaxConnectionsPerSecond, HTTPTimeout and MaxBytes can't be negative")
+	}
+
+	return nil
+}
+
+// latestMaster returns the Config with MasterPath set to the latest file by
+// name matching MasterGlob, if set
+func (c Config) latestMaster() (Config, error) {
+	if c.MasterGlob == "" {
+		return c, nil
+	}
+
+	matches, err := filepath.Glob(c.MasterGlob)
+	if err != nil {
+		return c, fmt.Errorf("invalid MasterGlob %s. %s", c.MasterGlob, err)
+	}
+
+	if len(matches) == 0 {
+		return c, fmt.Errorf("could not find a master matching %s", c.MasterGlob)
+	}
+
+	sort.Strings(matches)
+	c.MasterPath = matches[len(matches)-1]
+
+	return c, nil
+}
+
+// dryRun prints what a file-mutating operation would do, returning true if
+// Config.DryRun is set and the operation must be skipped
+func (c Config) dryRun(format string, args ...interface{}) bool {
+	if !c.DryRun {
+		return false
+	}
+
+	out := c.DryRunOutput
+	if out == nil {
+		out = os.Stdout
+	}
+
+	// DryRunOutput may be shared by concurrent operations
+	stdout.Lock()
+	defer stdout.Unlock()
+
+	fmt.Fprintf(out, "dry run: "+format+"\n", args...)
+
+	return true
+}
+
+// logger returns the configured logger, or one that discards everything
+func (c Config) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+
+	return c.Logger
 }
@@ -0,0 +1,55 @@
+package cfg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		c        Config
+		expected string
+	}{
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"}, ""},
+		{Config{WorkingPath: "test/a.env", MasterFromEnv: "MASTER"}, ""},
+		{Config{WorkingPath: "test/a.env", MasterGitRef: "main", MasterGitPath: ".env"}, ""},
+		{Config{WorkingPath: "test/a.env", MasterPaths: []string{"test/b.env", "test/c.env"}}, ""},
+		{Config{MasterPath: "test/b.env"}, "WorkingPath is required"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", MasterPaths: []string{"test/c.env"}}, "MasterPaths can't be used"},
+		{Config{WorkingPath: "test/a.env", MasterPaths: []string{"https://example.com/.env"}}, "can't hold a url"},
+		{Config{WorkingPath: "test/a.env"}, "one of MasterPath, MasterPaths, MasterGlob or MasterFromEnv is required"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", MasterFromEnv: "MASTER"}, "mutually exclusive"},
+		{Config{WorkingPath: "test/a.env", MasterGitPath: ".env"}, "requires MasterGitRef"},
+		{Config{WorkingPath: "test/a.env", MasterPath: ".env.example", MasterGitRef: "--output=x"}, "can't begin with -"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", Format: "csv"}, "unsupported format csv"},
+		{Config{WorkingPath: "test/a.json", MasterPath: "test/b.json", PathStyle: "slashed"}, "unsupported path style slashed"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "https://example.com/.env", HostAlias: "host"}, "url MasterPath"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "/etc/secrets", HostAlias: "host", Format: FormatDir}, "can't be a directory"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", ProxyJump: "bastion"}, "requires HostAlias"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", HostAlias: "host", SSHHost: "host"}, "mutually exclusive"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", SSHUser: "deploy"}, "require SSHHost"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", SSHHost: "host", SSHPort: 70000}, "valid port"},
+		{Config{WorkingPath: "test/a.env", MasterFromEnv: "MASTER", SSHHost: "host"}, "SSHHost can't be used with MasterFromEnv"},
+		{Config{WorkingPath: "test/a.json", MasterPath: "test/b.json", ArrayModes: map[string]ArrayMode{"*": "bag"}}, "unsupported array mode bag"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", MaxBytes: -1}, "can't be negative"},
+		{Config{WorkingPath: "test/a.env", MasterPath: "https://example.com/.env", ClientCert: "client.crt"}, "must be set together"},
+		{Config{WorkingPath: "test/a.env", MasterGlob: "test/*.env", MasterPath: "test/b.env"}, "MasterGlob can't be used"},
+		{Config{WorkingPath: "test/a.env", MasterGlob: "test/*.env", HostAlias: "host"}, "requires local master files"},
+		{Config{WorkingPath: "test/a.json", MasterPath: "test/b.json", CompareComments: true}, "CompareComments requires env files"},
+	}
+
+	for _, tt := range tests {
+		err := tt.c.Validate()
+
+		if tt.expected == "" {
+			if err != nil {
+				t.Fatalf("expected no error actual=%s", err)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Fatalf("expected=%s actual=%v", tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// the markers git leaves around the two sides of a merge conflict
+var (
+	conflictStart     = []byte("<<<<<<< ")
+	conflictSeparator = []byte("=======")
+	conflictEnd       = []byte(">>>>>>> ")
+)
+
+// checkConflicts returns an error naming the line of the first git merge
+// conflict marker left in a file, which would otherwise fail to parse with a
+// cryptic error. a file only has conflicts if it has a start marker
+func checkConflicts(b []byte, path string) error {
+	if !bytes.HasPrefix(b, conflictStart) && !bytes.Contains(b, append([]byte("\n"), conflictStart...)) {
+		return nil
+	}
+
+	for i, line := range bytes.Split(b, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+
+		if bytes.HasPrefix(line, conflictStart) || bytes.Equal(line, conflictSeparator) || bytes.HasPrefix(line, conflictEnd) {
+			return fmt.Errorf("%s contains unresolved merge conflict markers at line %d", path, i+1)
+		}
+	}
+
+	return nil
+}
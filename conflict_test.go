@@ -0,0 +1,49 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckConflicts(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/conflict/working.json",
+		MasterPath:  "test/a.json",
+	}
+
+	_, err := Scan(c)
+	if err == nil {
+		t.Fatal("expected an error for conflict markers")
+	}
+
+	expected := "test/conflict/working.json contains unresolved merge conflict markers at line 3"
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected=%s actual=%s", expected, err)
+	}
+
+	// a separator alone, e.g. in a value, isn't a conflict
+	if err := checkConflicts([]byte("A=1\n=======\nB=2\n"), ".env"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckConflictsWorkings(t *testing.T) {
+	c := Config{MasterPath: "test/a.json"}
+
+	_, err := ScanWorkings([]string{"test/conflict/working.json"}, c)
+	if err == nil || !strings.Contains(err.Error(), "contains unresolved merge conflict markers") {
+		t.Fatalf("expected an error for conflict markers actual=%v", err)
+	}
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.env"), []byte("A=1\n<<<<<<< HEAD\nB=2\n=======\nB=3\n>>>>>>> main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ScanArchive(Config{WorkingPath: dir, MasterPath: "test/release.zip"})
+	if err == nil || !strings.Contains(results["app.env"].Error, "contains unresolved merge conflict markers at line 2") {
+		t.Fatalf("expected an error for conflict markers actual=%v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package cfg
+
+import (
+	"strings"
+)
+
+// KeyConvention is how the flat keys of an env file map to the nested paths
+// of another format when Config.CompareFormats is set
+type KeyConvention string
+
+const (
+	// KeyUpperSnake maps DB_HOST to db.host, splitting on underscores and
+	// lowercasing, the default
+	KeyUpperSnake KeyConvention = "upper_snake"
+
+	// KeyDotted maps db.host to db.host, splitting on dots and keeping the
+	// case of each key
+	KeyDotted KeyConvention = "dotted"
+)
+
+// fileFormat returns the format a file is decoded in. each file is read in
+// the format of its own extension when the working and master may differ,
+// otherwise in the format of the scan
+func (c Config) fileFormat(path string) Format {
+	if !c.CompareFormats && c.EnvSeparator == "" {
+		return c.format()
+	}
+
+	return formatOf(path)
+}
+
+// flattened determines if the file at path is a flat env file to un-flatten
+// into nested keys, to compare against a nested file
+func (c Config) flattened(path string) bool {
+	return (c.CompareFormats || c.EnvSeparator != "") && formatOf(path) == FormatEnv
+}
+
+// unflatten splits a flat env key into the parts of a nested path, by
+// Config.EnvSeparator when set, otherwise by Config.KeyConvention
+func (c Config) unflatten(key string) []string {
+	switch {
+	case c.EnvSeparator != "":
+		return strings.Split(strings.ToLower(key), c.EnvSeparator)
+	case c.KeyConvention == KeyDotted:
+		return strings.Split(key, ".")
+	}
+
+	return strings.Split(strings.ToLower(key), "_")
+}
@@ -0,0 +1,57 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCompareFormats(t *testing.T) {
+	tests := []struct {
+		working, master string
+		convention      KeyConvention
+		missing         []string
+		different       []string
+	}{
+		{"test/formats/working.env", "test/formats/config.example.json", "", []string{"cache"}, []string{"db.host"}},
+		{"test/formats/dotted.env", "test/formats/config.example.json", KeyDotted, []string{}, []string{}},
+		{"test/formats/working.yaml", "test/formats/config.example.json", "", []string{"cache"}, []string{}},
+		{"test/formats/working.yaml", "test/formats/config.example.toml", "", []string{"cache"}, []string{}},
+		{"test/formats/working.env", "test/formats/config.example.toml", KeyUpperSnake, []string{"cache"}, []string{"db.host"}},
+		// the flat side may be the master
+		{"test/formats/config.example.json", "test/formats/working.env", "", []string{}, []string{"db.host"}},
+	}
+
+	for _, tt := range tests {
+		r, err := Scan(Config{WorkingPath: tt.working, MasterPath: tt.master, CompareFormats: true, KeyConvention: tt.convention})
+		if err != nil {
+			t.Fatalf("working=%s master=%s %s", tt.working, tt.master, err)
+		}
+
+		different := []string{}
+		for _, d := range r.Different {
+			different = append(different, d.Key)
+		}
+
+		missing := append([]string{}, r.Missing...)
+		sort.Strings(missing)
+		sort.Strings(different)
+
+		if !reflect.DeepEqual(missing, tt.missing) || !reflect.DeepEqual(different, tt.different) {
+			t.Fatalf("working=%s master=%s expected missing=%v different=%v actual missing=%v different=%v",
+				tt.working, tt.master, tt.missing, tt.different, missing, different)
+		}
+	}
+}
+
+func TestCompareFormatsInvalid(t *testing.T) {
+	c := Config{WorkingPath: "test/formats/working.env", MasterPath: "test/formats/config.example.json", KeyConvention: KeyDotted}
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected KeyConvention to require CompareFormats")
+	}
+
+	c.CompareFormats, c.KeyConvention = true, "kebab"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an unsupported key convention")
+	}
+}
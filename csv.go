@@ -0,0 +1,84 @@
+package cfg
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// PrintCSV writes one row per discrepancy between the working and master
+// files to w with the columns key, category (missing, extra, forbidden or
+// different), masterValue and workingValue. values are quoted per RFC 4180
+// where needed. values of missing and extra keys are only known for env files.
+// the values of sensitive keys are redacted
+func PrintCSV(c Config, w io.Writer) error {
+	base, err := newAnalyzer(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.format()
+
+	result, err := base.scan(format)
+	if err != nil {
+		return err
+	}
+
+	master, working := map[string]string{}, map[string]string{}
+	if format == FormatEnv {
+		if master, err = envValues(base.master); err != nil {
+			return err
+		}
+		if working, err = envValues(base.working); err != nil {
+			return err
+		}
+	}
+
+	c.redactValues(master)
+	c.redactValues(working)
+
+	return writeCSV(w, result, master, working)
+}
+
+// writeCSV writes the rows of PrintCSV for a Result to w, taking the values
+// of missing and extra keys from master and working
+func writeCSV(w io.Writer, result *Result, master, working map[string]string) error {
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"key", "category", "masterValue", "workingValue"})
+
+	for _, key := range result.Missing {
+		writer.Write([]string{key, "missing", master[key], ""})
+	}
+
+	for _, key := range result.Extra {
+		writer.Write([]string{key, "extra", "", working[key]})
+	}
+
+	for _, key := range result.Forbidden {
+		writer.Write([]string{key, "forbidden", "", working[key]})
+	}
+
+	for _, d := range result.Different {
+		writer.Write([]string{d.Key, "different", d.Master, d.Working})
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// envValues parses env file bytes into a map of key value pairs
+func envValues(b []byte) (map[string]string, error) {
+	env, err := envAnalyzer{}.unmarshal(strings.Split(string(b), "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, e := range env {
+		values[e.Key] = e.Value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,29 @@
+package cfg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintCSV(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/i.env",
+		MasterPath:  "test/j.env",
+	}
+
+	var buf bytes.Buffer
+	if err := PrintCSV(c, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `key,category,masterValue,workingValue
+NAME,missing,"cfg, ""the"" analyzer",
+EXTRA,extra,,1
+GREETING,different,hello,"""hello, world"""
+HOSTS,different,a,"a,b"
+`
+
+	if buf.String() != expected {
+		t.Fatalf("expected=%s actual=%s", expected, buf.String())
+	}
+}
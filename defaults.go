@@ -0,0 +1,92 @@
+package cfg
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// readDefaults reads the keys of Config.DefaultsPath, the lowest tier of a
+// layered config, if set
+func (a *analyzer) readDefaults(format Format) error {
+	if a.config.DefaultsPath == "" {
+		return nil
+	}
+
+	path, err := resolve(a.config.DefaultsPath)
+	if err != nil {
+		return fmt.Errorf("could not open %s. %s", a.config.DefaultsPath, err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not open %s. %s", a.config.DefaultsPath, err)
+	}
+
+	if b, err = a.decode(b, a.config.DefaultsPath); err != nil {
+		return err
+	}
+
+	keys, err := keysOf(b, format, a.config.keySeparator())
+	if err != nil {
+		return fmt.Errorf("could not parse %s. %s", a.config.DefaultsPath, err)
+	}
+
+	a.defaults = map[string]bool{}
+	for key := range keys {
+		if a.config.KeyTransform != nil {
+			key = a.transformPath(key, format)
+		}
+		a.defaults[key] = true
+	}
+
+	a.log.Debug("read defaults file", "path", a.config.DefaultsPath, "keys", len(a.defaults))
+
+	return nil
+}
+
+// applyDefaults moves keys missing from the working file that the defaults
+// provide from missing to usingDefault, and drops extra keys that the
+// defaults know of
+func (a *analyzer) applyDefaults() {
+	if a.defaults == nil {
+		return
+	}
+
+	missing := []string{}
+	for _, key := range a.missing {
+		if a.defaults[key] {
+			a.usingDefault = append(a.usingDefault, key)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+
+	extra := []string{}
+	for _, key := range a.extra {
+		if !a.defaults[key] {
+			extra = append(extra, key)
+		}
+	}
+
+	a.missing, a.extra = missing, extra
+}
+
+// transformPath applies Config.KeyTransform to a key, or to each part of a
+// json path
+func (a analyzer) transformPath(key string, format Format) string {
+	if !format.nested() {
+		return a.config.KeyTransform(key)
+	}
+
+	sep := a.config.keySeparator()
+
+	path := ""
+	for i, part := range splitPath(key, sep) {
+		if i > 0 {
+			path += sep
+		}
+		path = joinKey(path, a.config.KeyTransform(part), sep)
+	}
+
+	return path
+}
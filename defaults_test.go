@@ -0,0 +1,35 @@
+package cfg
+
+import "testing"
+
+func TestScanDefaults(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/defaults/working.env",
+		MasterPath:   "test/defaults/master.env",
+		DefaultsPath: "test/defaults/defaults.env",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ANIMAL falls back to its default, while SPORT is known to the defaults
+	// so isn't extra
+	if len(result.Missing) != 1 || result.Missing[0] != "COLOUR" {
+		t.Fatalf("expected=[COLOUR] actual=%+v", result.Missing)
+	}
+
+	if len(result.UsingDefault) != 1 || result.UsingDefault[0] != "ANIMAL" {
+		t.Fatalf("expected=[ANIMAL] actual=%+v", result.UsingDefault)
+	}
+
+	if len(result.Extra) != 0 {
+		t.Fatalf("expected no extra keys actual=%+v", result.Extra)
+	}
+
+	c.DefaultsPath = "test/defaults/missing.env"
+	if _, err := Scan(c); err == nil {
+		t.Fatal("expected an error for a missing defaults file")
+	}
+}
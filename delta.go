@@ -0,0 +1,110 @@
+package cfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeltaResult holds the changes between two scans of the same files
+type DeltaResult struct {
+	// NewlyMissing holds keys missing now that weren't missing previously
+	NewlyMissing []string
+
+	// NewlyDifferent holds differences found now that weren't previously
+	NewlyDifferent []DiffEntry
+
+	// Resolved holds keys that were previously missing or different and no
+	// longer are
+	Resolved []string
+}
+
+// DeltaSince compares the current Result against a previously stored one,
+// reporting only what has drifted or been fixed in between. a nil prev
+// treats everything in current as new
+func DeltaSince(prev *Result, current *Result) *DeltaResult {
+	if prev == nil {
+		prev = &Result{}
+	}
+
+	delta := &DeltaResult{}
+
+	prevMissing := set(prev.Missing)
+	for _, key := range current.Missing {
+		if !prevMissing[key] {
+			delta.NewlyMissing = append(delta.NewlyMissing, key)
+		}
+	}
+
+	prevDifferent := map[string]DiffEntry{}
+	for _, d := range prev.Different {
+		prevDifferent[d.Key] = d
+	}
+
+	for _, d := range current.Different {
+		// a key whose values changed again since the last scan has drifted anew
+		if p, ok := prevDifferent[d.Key]; !ok || p != d {
+			delta.NewlyDifferent = append(delta.NewlyDifferent, d)
+		}
+	}
+
+	currentKeys := set(current.Missing)
+	for _, d := range current.Different {
+		currentKeys[d.Key] = true
+	}
+
+	for _, key := range prev.Missing {
+		if !currentKeys[key] {
+			delta.Resolved = append(delta.Resolved, key)
+		}
+	}
+
+	for _, d := range prev.Different {
+		if !currentKeys[d.Key] && !prevMissing[d.Key] {
+			delta.Resolved = append(delta.Resolved, d.Key)
+		}
+	}
+
+	return delta
+}
+
+// set returns a lookup map of the given keys
+func set(keys []string) map[string]bool {
+	m := map[string]bool{}
+	for _, k := range keys {
+		m[k] = true
+	}
+	return m
+}
+
+// CompareResults reports whether the current Result has regressed from the old
+// one, having more missing or different keys, along with a line per key that
+// is newly missing or different, e.g. for a gate that drift must not grow
+func CompareResults(old, current *Result) (regressed bool, details string) {
+	if old == nil {
+		old = &Result{}
+	}
+
+	regressed = len(current.Missing) > len(old.Missing) || len(current.Different) > len(old.Different)
+
+	var b strings.Builder
+
+	oldMissing := set(old.Missing)
+	for _, key := range current.Missing {
+		if !oldMissing[key] {
+			fmt.Fprintf(&b, "missing: %s\n", key)
+		}
+	}
+
+	oldDifferent := map[string]bool{}
+	for _, d := range old.Different {
+		oldDifferent[d.Key] = true
+	}
+
+	for _, d := range current.Different {
+		if !oldDifferent[d.Key] {
+			fmt.Fprintf(&b, "different: %s\n", d.Key)
+		}
+	}
+
+	return regressed, b.String()
+}
@@ -0,0 +1,69 @@
+package cfg
+
+import "testing"
+
+func TestDeltaSince(t *testing.T) {
+	prev := &Result{
+		Missing:   []string{"FOOD", "LANG"},
+		Different: []DiffEntry{{Key: "SPORT", Master: "Rugby", Working: "Football"}},
+	}
+
+	current := &Result{
+		Missing: []string{"LANG", "DRINK"},
+		Different: []DiffEntry{
+			{Key: "SPORT", Master: "Rugby", Working: "Football"},
+			{Key: "FRUIT", Master: "Mango", Working: "Guava"},
+		},
+	}
+
+	delta := DeltaSince(prev, current)
+
+	if len(delta.NewlyMissing) != 1 || delta.NewlyMissing[0] != "DRINK" {
+		t.Fatalf("expected=[DRINK] actual=%+v", delta.NewlyMissing)
+	}
+
+	if len(delta.NewlyDifferent) != 1 || delta.NewlyDifferent[0].Key != "FRUIT" {
+		t.Fatalf("expected=[FRUIT] actual=%+v", delta.NewlyDifferent)
+	}
+
+	if len(delta.Resolved) != 1 || delta.Resolved[0] != "FOOD" {
+		t.Fatalf("expected=[FOOD] actual=%+v", delta.Resolved)
+	}
+}
+
+func TestDeltaSinceNil(t *testing.T) {
+	current := &Result{Missing: []string{"FOOD"}}
+
+	delta := DeltaSince(nil, current)
+
+	if len(delta.NewlyMissing) != 1 || len(delta.Resolved) != 0 {
+		t.Fatalf("expected everything to be new actual=%+v", delta)
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	old := &Result{
+		Missing:   []string{"A", "B"},
+		Different: []DiffEntry{{Key: "C"}},
+	}
+
+	new := &Result{
+		Missing:   []string{"B", "D"},
+		Different: []DiffEntry{{Key: "C"}, {Key: "E"}},
+	}
+
+	regressed, details := CompareResults(old, new)
+	if !regressed {
+		t.Fatal("expected a regression for a new different key")
+	}
+
+	if expected := "missing: D\ndifferent: E\n"; details != expected {
+		t.Fatalf("expected=%s actual=%s", expected, details)
+	}
+
+	// fixing as many keys as were broken isn't a regression
+	new.Different = old.Different
+	if regressed, _ := CompareResults(old, new); regressed {
+		t.Fatal("expected no regression")
+	}
+}
@@ -0,0 +1,96 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// readDir reads a directory of key-per-file secrets, where each file name is
+// a key and its contents the value, encoding it in the given format so it can
+// be compared like any other config file. subdirectories become nested keys,
+// dotted for env. entries starting with "." are skipped, which also skips the
+// ..data links of kubernetes secret mounts
+func readDir(path string, format Format) ([]byte, error) {
+	values, err := walkDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if format.nested() {
+		return json.Marshal(values)
+	}
+
+	lines := []string{}
+	flattenDir(values, "", &lines)
+	sort.Strings(lines)
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// walkDir reads each file within a directory into a map, drilling down into
+// subdirectories as nested maps. symlinks are followed
+func walkDir(path string) (map[string]interface{}, error) {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		full := filepath.Join(path, entry.Name())
+
+		info, err := os.Stat(full)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			if values[entry.Name()], err = walkDir(full); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		b, err := ioutil.ReadFile(full)
+		if err != nil {
+			return nil, err
+		}
+
+		// secrets are commonly written with a trailing newline
+		values[entry.Name()] = strings.TrimSuffix(string(b), "\n")
+	}
+
+	return values, nil
+}
+
+// flattenDir stores each value of a nested map as a dotted KEY=value line.
+// multi-line values, e.g. PEM encoded keys, are double quoted so that they
+// parse back as a single value
+func flattenDir(values map[string]interface{}, prefix string, lines *[]string) {
+	for k, v := range values {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenDir(nested, prefix+k+".", lines)
+			continue
+		}
+
+		value := v.(string)
+		if strings.Contains(value, "\n") {
+			value = `"` + envQuoter.Replace(value) + `"`
+		}
+
+		*lines = append(*lines, fmt.Sprintf("%s%s=%s", prefix, k, value))
+	}
+}
+
+// envQuoter escapes the characters that would end a double quoted env value
+var envQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
@@ -0,0 +1,73 @@
+package cfg
+
+import "testing"
+
+func TestReadDirEnv(t *testing.T) {
+	b, err := readDir("test/secrets", FormatEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ANIMAL=Koala\nFRUIT=Mango\nTOKEN=c2VjcmV0\ndb.host=localhost"
+	if string(b) != expected {
+		t.Fatalf("expected=%q actual=%q", expected, b)
+	}
+}
+
+func TestScanDirAgainstEnv(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/secrets",
+		MasterPath:  "test/b.env",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// FOOD, LANG, SPORT and DRINK are absent from the secrets directory
+	if len(result.Missing) != 4 {
+		t.Fatalf("expected=%d actual=%+v", 4, result.Missing)
+	}
+
+	if len(result.Extra) != 2 {
+		t.Fatalf("expected=[TOKEN db.host] actual=%+v", result.Extra)
+	}
+}
+
+func TestScanDirAgainstJson(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/secrets",
+		MasterPath:  "test/g.json",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// db.port, db.user and app are absent from the secrets directory
+	if len(result.Missing) != 3 {
+		t.Fatalf("expected=%d actual=%+v", 3, result.Missing)
+	}
+}
+
+func TestScanDirMultiline(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/multiline/secrets",
+		MasterPath:  "test/multiline/master.env",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Malformed) != 0 {
+		t.Fatalf("expected no malformed lines actual=%+v", result.Malformed)
+	}
+
+	if len(result.Missing) != 0 || len(result.Extra) != 0 || len(result.Different) != 0 {
+		t.Fatalf("expected no differences actual=%+v", result)
+	}
+}
@@ -0,0 +1,14 @@
+// Package cfg compares a working config file against a master, reporting
+// the keys missing from the working file, the keys it has that the master
+// doesn't and the keys whose values differ. env, json, yaml, toml, ini, java
+// properties, xml and hcl files are supported, read locally, over ssh,
+// http(s), from git or a docker container.
+//
+// Every exported function is safe to call from multiple goroutines. each
+// call works on its own copy of the files, the only state shared between
+// calls being the ssh connection throttle, an Analyzer's cached master and
+// the output of the Print functions and Config.DryRunOutput, which are
+// serialized so that concurrent reports don't interleave. a Config must not
+// be modified while a call using it is in progress, and its Logger,
+// DecryptFunc and KeyTransform must themselves be safe for concurrent use
+package cfg
@@ -0,0 +1,44 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// docker holds data for reading files from within a running docker container
+type docker struct {
+	container string
+}
+
+// newDocker returns a new docker for the container of the given name or id
+func newDocker(container string) *docker {
+	return &docker{
+		container: container,
+	}
+}
+
+// cat reads a file from within the container via docker exec
+func (d docker) cat(path string) ([]byte, error) {
+	return d.command("exec", d.container, "cat", path)
+}
+
+// command runs the docker cli with the given args, returning its stdout. its
+// stderr is included in the error on failure
+func (d docker) command(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s. %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return out, nil
+}
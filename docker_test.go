@@ -0,0 +1,54 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeDocker puts a docker executable on the PATH that serves `docker exec
+// <container> cat <path>` from the local filesystem, for the "app" container
+func fakeDocker(t *testing.T) {
+	dir := t.TempDir()
+
+	script := `#!/bin/sh
+[ "$1" = exec ] && [ "$2" = app ] && [ "$3" = cat ] || { echo "No such container: $2" >&2; exit 1; }
+exec cat "$4"
+`
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestScanContainer(t *testing.T) {
+	fakeDocker(t)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: filepath.Join(wd, "test/a.env"),
+		MasterPath:  "test/b.env",
+		Container:   "app",
+	}
+
+	keys, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected=%d actual=%d", 3, len(keys))
+	}
+
+	c.Container = "missing"
+	if _, err := ScanEnv(c); err == nil {
+		t.Fatal("expected an error for a missing container")
+	}
+}
@@ -1,8 +1,8 @@
 package cfg
 
 import (
-	"errors"
 	"fmt"
+	"os"
 	"strings"
 )
 
@@ -10,6 +10,9 @@ import (
 type configEnv struct {
 	Key   string
 	Value string
+
+	// Comment holds the "#" lines directly above the key, without their "#"
+	Comment string
 }
 
 // envAnalyzer holds data for both working and master .env config files
@@ -27,35 +30,110 @@ func newEnvAnalyzer(c Config) (*envAnalyzer, error) {
 		return nil, err
 	}
 
-	analyzer := envAnalyzer{}
+	return loadEnvAnalyzer(base)
+}
 
-	working := strings.Split(string(base.working), "\n")
-	master := strings.Split(string(base.master), "\n")
+// loadEnvAnalyzer returns a new envAnalyzer loaded with the key value pairs
+// of the base analyzer's working and master files
+func loadEnvAnalyzer(base *analyzer) (*envAnalyzer, error) {
+	c := base.config
+	analyzer := envAnalyzer{analyzer: *base}
 
-	analyzer.envWorking, err = analyzer.unmarshal(working)
-	if err != nil {
-		return nil, err
+	// malformed lines are reported rather than failing the whole scan
+	working, malformed := analyzer.parse(strings.Split(string(base.working), "\n"))
+	for _, line := range malformed {
+		analyzer.malformed = append(analyzer.malformed, c.WorkingPath+":"+line)
 	}
 
-	analyzer.envMaster, err = analyzer.unmarshal(master)
-	if err != nil {
+	// interpolate references, noting any to keys the working file doesn't define
+	if c.ExpandEnv {
+		analyzer.dangling = analyzer.expand(working)
+	}
+
+	if c.KeyTransform != nil {
+		analyzer.transformKeys(working)
+	}
+
+	working = base.ignoreKeys.env(working)
+
+	master := analyzer.prepareEnvMaster
+	if base.cache != nil {
+		master = func() parsedEnv { return base.cache.env(base.master, analyzer.prepareEnvMaster) }
+	}
+
+	m := master()
+	analyzer.envWorking, analyzer.envMaster = working, m.env
+	analyzer.malformed = append(analyzer.malformed, m.malformed...)
+
+	if len(analyzer.malformed) > 0 {
+		analyzer.log.Warn("found malformed lines", "lines", analyzer.malformed)
+	}
+
+	if err := analyzer.readDefaults(FormatEnv); err != nil {
 		return nil, err
 	}
 
+	analyzer.log.Debug("parsed env config", "working_keys", len(analyzer.envWorking),
+		"master_keys", len(analyzer.envMaster))
+
 	return &analyzer, nil
 }
 
+// parsedEnv is a parsed master env file and its malformed lines
+type parsedEnv struct {
+	env       []configEnv
+	malformed []string
+}
+
+// prepareEnvMaster parses the master file, applying Config.ExpandEnv,
+// Config.KeyTransform and Config.Ignore
+func (e envAnalyzer) prepareEnvMaster() parsedEnv {
+	env, malformed := e.parse(strings.Split(string(e.master), "\n"))
+
+	m := parsedEnv{env: env}
+	for _, line := range malformed {
+		m.malformed = append(m.malformed, e.config.MasterPath+":"+line)
+	}
+
+	if e.config.ExpandEnv {
+		e.expand(env)
+	}
+
+	if e.config.KeyTransform != nil {
+		e.transformKeys(env)
+	}
+
+	m.env = e.ignoreKeys.env(env)
+
+	return m
+}
+
 // scan will analyze two sets of env key value pairs identifying:
 // 1) keys that exist in the master file and are missing in the working file
 // 2) keys that exists but have different values
+// 3) keys that exist in the working file and are missing in the master file
+// 4) keys in the working file that are forbidden
+// 5) keys in both files at different positions, if Config.CheckOrder is set
+// 6) missing keys that fall back to Config.DefaultsPath, if set
 func (e *envAnalyzer) scan() {
 	for _, master := range e.envMaster {
 		exists := false
 		for _, working := range e.envWorking {
 			if master.Key == working.Key {
-				if master.Value != working.Value {
-					e.different = append(e.different,
-						fmt.Sprintf("%s=%s", working.Key, working.Value))
+				if !e.equalValues(master.Key, master.Value, working.Value) {
+					e.different = append(e.different, e.config.redact(DiffEntry{
+						Key:     working.Key,
+						Master:  master.Value,
+						Working: working.Value,
+					}))
+				}
+
+				if e.config.CompareComments && master.Comment != working.Comment {
+					e.comments = append(e.comments, DiffEntry{
+						Key:     working.Key,
+						Master:  master.Comment,
+						Working: working.Comment,
+					})
 				}
 
 				exists = true
@@ -63,33 +141,194 @@ func (e *envAnalyzer) scan() {
 		}
 
 		if !exists {
-			e.missing = append(e.missing, master.Key)
+			e.addMissing(master.Key, master.Value)
+		}
+	}
+
+	if !e.config.IgnoreExtra {
+		for _, working := range e.envWorking {
+			exists := false
+			for _, master := range e.envMaster {
+				if master.Key == working.Key {
+					exists = true
+					break
+				}
+			}
+
+			if !exists {
+				e.extra = append(e.extra, working.Key)
+			}
 		}
 	}
+
+	keys := []string{}
+	for _, working := range e.envWorking {
+		keys = append(keys, working.Key)
+	}
+
+	e.findForbidden(keys)
+
+	if e.config.CheckOrder {
+		e.findOrderChanged()
+	}
+
+	e.applyDefaults()
+}
+
+// findOrderChanged stores keys present in both files whose position amongst
+// the keys they have in common differs
+func (e *envAnalyzer) findOrderChanged() {
+	inMaster, inWorking := map[string]bool{}, map[string]bool{}
+	for _, master := range e.envMaster {
+		inMaster[master.Key] = true
+	}
+	for _, working := range e.envWorking {
+		inWorking[working.Key] = true
+	}
+
+	master, working := []string{}, []string{}
+	for _, m := range e.envMaster {
+		if inWorking[m.Key] {
+			master = append(master, m.Key)
+		}
+	}
+	for _, w := range e.envWorking {
+		if inMaster[w.Key] {
+			working = append(working, w.Key)
+		}
+	}
+
+	e.orderChanged = orderChanged(master, working)
+}
+
+// transformKeys applies Config.KeyTransform to each key in place
+func (e envAnalyzer) transformKeys(env []configEnv) {
+	for i := range env {
+		env[i].Key = e.config.KeyTransform(env[i].Key)
+	}
+}
+
+// expand interpolates ${KEY} and $KEY references within each value using the
+// keys defined in the same file, returning the references to keys that
+// aren't defined as "KEY -> REF". undefined references expand to ""
+func (e envAnalyzer) expand(env []configEnv) []string {
+	dangling := []string{}
+
+	values := map[string]string{}
+	for _, v := range env {
+		values[v.Key] = v.Value
+	}
+
+	for i := range env {
+		env[i].Value = os.Expand(env[i].Value, func(ref string) string {
+			value, ok := values[ref]
+			if !ok {
+				dangling = append(dangling, fmt.Sprintf("%s -> %s", env[i].Key, ref))
+			}
+			return value
+		})
+
+		values[env[i].Key] = env[i].Value
+	}
+
+	return dangling
+}
+
+// merged returns the working file with any keys missing from it appended
+// using their master values
+func (e *envAnalyzer) merged() []byte {
+	e.scan()
+
+	merged := strings.TrimRight(string(e.working), "\n") + "\n"
+
+	for _, key := range e.missing {
+		for _, master := range e.envMaster {
+			if master.Key == key {
+				merged += fmt.Sprintf("%s=%s\n", master.Key, master.Value)
+				break
+			}
+		}
+	}
+
+	return []byte(merged)
 }
 
 // unmarshal will unmarshal a slice of env vars into key value pairs (configEnv)
 func (e envAnalyzer) unmarshal(env []string) ([]configEnv, error) {
+	config, malformed := e.parse(env)
+	if len(malformed) > 0 {
+		return nil, fmt.Errorf("Invalid key value pair found in config at line %s", malformed[0])
+	}
+
+	return config, nil
+}
+
+// parse converts a slice of env lines into key value pairs, returning the
+// lines that aren't a valid pair separately as "line: text", their text after
+// any key redacted. a line without a "=" or with an empty key is malformed
+func (e envAnalyzer) parse(env []string) ([]configEnv, []string) {
 	config := []configEnv{}
+	malformed := []string{}
+
+	// the comment lines since the last key or blank line
+	comment := []string{}
 
-	for _, line := range env {
-		parts := strings.Split(line, "=")
+	for i := 0; i < len(env); i++ {
+		// files with windows line endings parse the same as any other
+		line := strings.TrimSuffix(env[i], "\r")
 
-		if line == "" || strings.Index(line, "#") == 0 {
+		if line == "" {
+			comment = comment[:0]
 			continue
 		}
 
-		if len(parts) != 2 {
-			return nil, errors.New("Invalid key value pair found in config")
+		if strings.Index(line, "#") == 0 {
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
+
+		// values may contain "=" themselves, e.g. base64 padding
+		parts := strings.SplitN(line, "=", 2)
+
+		if len(parts) != 2 || parts[0] == "" {
+			malformed = append(malformed, fmt.Sprintf("%d: %s", i+1, e.config.redactLine(line)))
+			comment = comment[:0]
+			continue
+		}
+
+		// a double quoted value may span several lines, up to its closing
+		// quote, e.g. a PEM encoded PRIVATE_KEY. a quote that is never closed
+		// is malformed and the lines after it are parsed as usual
+		if unclosed(parts[1]) {
+			value, end := parts[1], i
+			for unclosed(value) && end+1 < len(env) {
+				end++
+				value += "\n" + strings.TrimSuffix(env[end], "\r")
+			}
+
+			if unclosed(value) {
+				malformed = append(malformed, fmt.Sprintf("%d: %s", i+1, e.config.redactLine(line)))
+				comment = comment[:0]
+				continue
+			}
+
+			parts[1], i = value, end
 		}
 
 		c := configEnv{
-			Key:   parts[0],
-			Value: parts[1],
+			Key:     parts[0],
+			Value:   parts[1],
+			Comment: strings.Join(comment, "\n"),
 		}
 
 		config = append(config, c)
+		comment = comment[:0]
 	}
 
-	return config, nil
+	return config, malformed
+}
+
+// unclosed determines if a value opens a double quote it doesn't close
+func unclosed(value string) bool {
+	return strings.HasPrefix(value, `"`) && quoteEnd([]byte(value[1:])) < 0
 }
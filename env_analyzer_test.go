@@ -1,6 +1,10 @@
 package cfg
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -66,3 +70,457 @@ func TestEnvWorking(t *testing.T) {
 		}
 	}
 }
+
+func TestEnvTokenMap(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/e.env",
+		MasterPath:  "test/f.env",
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if len(analyzer.different) != 3 {
+		t.Fatalf("expected=%d actual=%d", 3, len(analyzer.different))
+	}
+
+	c.EnvTokenMap = map[string]string{"staging": "prod"}
+
+	analyzer, err = newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	expected := "REGION=us-east-1"
+	if len(analyzer.different) != 1 || analyzer.different[0].String() != expected {
+		t.Fatalf("expected=%s actual=%+v", expected, analyzer.different)
+	}
+}
+
+func TestEnvListValueKeys(t *testing.T) {
+	c := Config{
+		WorkingPath:   "test/g.env",
+		MasterPath:    "test/h.env",
+		ListValueKeys: []string{"ALLOWED_*"},
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	// ALLOWED_HOSTS is only reordered, ALLOWED_ORIGINS holds a different set
+	// and ADMINS is not a list key
+	expected := []string{"ALLOWED_ORIGINS=x.example.com, y.example.com", "ADMINS=alice,bob"}
+
+	if len(analyzer.different) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.different)
+	}
+
+	for i := range expected {
+		if analyzer.different[i].String() != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], analyzer.different[i])
+		}
+	}
+}
+
+func TestEnvBase64Keys(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/u.env",
+		MasterPath:  "test/v.env",
+		Base64Keys:  []string{"CERT", "TOKEN"},
+
+		// the decoded values are compared, not redacted
+		RedactPatterns: []string{},
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	// CERT is only missing its padding, TOKEN decodes to different bytes and
+	// SALT is not a base64 key
+	expected := []string{"TOKEN=b25l", "SALT=YQ"}
+
+	if len(analyzer.different) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.different)
+	}
+
+	for i := range expected {
+		if analyzer.different[i].String() != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], analyzer.different[i])
+		}
+	}
+}
+
+func TestEnvForbiddenKeys(t *testing.T) {
+	c := Config{
+		WorkingPath:   "test/g.env",
+		MasterPath:    "test/h.env",
+		ForbiddenKeys: []string{"ADMINS", "LEGACY_*"},
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if len(analyzer.forbidden) != 1 || analyzer.forbidden[0] != "ADMINS" {
+		t.Fatalf("expected=[ADMINS] actual=%+v", analyzer.forbidden)
+	}
+}
+
+func TestEnvPlaceholders(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/k.env",
+		MasterPath:   "test/l.env",
+		Placeholders: []string{"__CHANGEME__", "<*>"},
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	// placeholder values are not compared, but their keys must still exist
+	if len(analyzer.different) != 1 || analyzer.different[0].Key != "REGION" {
+		t.Fatalf("expected=[REGION] actual=%+v", analyzer.different)
+	}
+
+	if len(analyzer.missing) != 1 || analyzer.missing[0] != "DB_PASSWORD" {
+		t.Fatalf("expected=[DB_PASSWORD] actual=%+v", analyzer.missing)
+	}
+}
+
+func TestEnvExpandDanglingRefs(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/n.env",
+		MasterPath:  "test/o.env",
+		ExpandEnv:   true,
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"CALLBACK -> MISSING_BASE", "HOME_DIR -> HOME_ROOT"}
+
+	if len(analyzer.dangling) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.dangling)
+	}
+
+	for i := range expected {
+		if analyzer.dangling[i] != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], analyzer.dangling[i])
+		}
+	}
+
+	analyzer.scan()
+
+	// URL expands to the master value
+	for _, d := range analyzer.different {
+		if d.Key == "URL" {
+			t.Fatalf("expected URL to be equal once expanded actual=%+v", d)
+		}
+	}
+}
+
+func TestEnvIgnoreValuePatterns(t *testing.T) {
+	c := Config{
+		WorkingPath:         "test/p.env",
+		MasterPath:          "test/q.env",
+		IgnoreValuePatterns: []string{`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`},
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	// the two timestamps are suppressed, the build hash and region are not
+	expected := []string{"BUILD_SHA", "REGION"}
+
+	if len(analyzer.different) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.different)
+	}
+
+	for i := range expected {
+		if analyzer.different[i].Key != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], analyzer.different[i].Key)
+		}
+	}
+
+	c.IgnoreValuePatterns = []string{"("}
+	if _, err := newEnvAnalyzer(c); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
+
+func TestEnvCheckOrder(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/r.env",
+		MasterPath:  "test/b.env",
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if len(analyzer.orderChanged) != 0 {
+		t.Fatalf("expected order to be ignored by default actual=%+v", analyzer.orderChanged)
+	}
+
+	c.CheckOrder = true
+
+	analyzer, err = newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	// FRUIT and ANIMAL swapped places, SPORT and DRINK are where master has them
+	expected := []string{"FRUIT", "ANIMAL"}
+
+	if len(analyzer.orderChanged) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.orderChanged)
+	}
+
+	for i := range expected {
+		if analyzer.orderChanged[i] != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], analyzer.orderChanged[i])
+		}
+	}
+}
+
+func TestEnvMalformed(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/w.env",
+		MasterPath:  "test/x.env",
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	expected := []string{
+		"test/w.env:2: JUST_A_KEY_NO_EQUALS",
+		"test/w.env:4: =*****",
+	}
+
+	if len(analyzer.malformed) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.malformed)
+	}
+
+	for i := range expected {
+		if analyzer.malformed[i] != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], analyzer.malformed[i])
+		}
+	}
+
+	if len(analyzer.missing) != 1 || analyzer.missing[0] != "JUST_A_KEY" {
+		t.Fatalf("expected=[JUST_A_KEY] actual=%+v", analyzer.missing)
+	}
+}
+
+func TestEnvKeyTransform(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/transform/working.env",
+		MasterPath:  "test/transform/master.env",
+		KeyTransform: func(k string) string {
+			return strings.ReplaceAll(strings.TrimPrefix(k, "APP_"), "-", "_")
+		},
+	}
+
+	analyzer, err := newEnvAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if len(analyzer.missing) != 1 || analyzer.missing[0] != "NAME" {
+		t.Fatalf("expected=[NAME] actual=%+v", analyzer.missing)
+	}
+
+	if len(analyzer.different) != 1 || analyzer.different[0].String() != "DB_HOST=localhost" {
+		t.Fatalf("expected=[DB_HOST=localhost] actual=%+v", analyzer.different)
+	}
+}
+
+func TestEnvTrailingNewline(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		working string
+		master  string
+	}{
+		{"FRUIT=Mango\nANIMAL=Koala", "FRUIT=Mango\nANIMAL=Koala\n"},
+		{"FRUIT=Mango\r\nANIMAL=Koala\r\n", "FRUIT=Mango\nANIMAL=Koala\n"},
+	}
+
+	for _, tt := range tests {
+		working, master := filepath.Join(dir, "working.env"), filepath.Join(dir, "master.env")
+
+		if err := ioutil.WriteFile(working, []byte(tt.working), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(master, []byte(tt.master), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Scan(Config{WorkingPath: working, MasterPath: master})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Missing)+len(result.Extra)+len(result.Different) != 0 {
+			t.Fatalf("expected=%q to equal %q actual=%+v", tt.working, tt.master, result)
+		}
+	}
+}
+
+func TestEnvMissingRequired(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/required/working.env",
+		MasterPath:   "test/required/master.env",
+		Placeholders: []string{"changeme"},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.MissingWithDefault) != 1 || result.MissingWithDefault[0] != "PORT" {
+		t.Fatalf("expected=[PORT] actual=%+v", result.MissingWithDefault)
+	}
+
+	if len(result.MissingRequired) != 2 || result.MissingRequired[0] != "SECRET" || result.MissingRequired[1] != "TOKEN" {
+		t.Fatalf("expected=[SECRET TOKEN] actual=%+v", result.MissingRequired)
+	}
+}
+
+func TestEnvMultilineValues(t *testing.T) {
+	c := Config{
+		WorkingPath:    "test/multiline/working.env",
+		MasterPath:     "test/multiline/master.env",
+		RedactPatterns: []string{},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Malformed) != 0 || len(result.Missing) != 0 || len(result.Extra) != 0 {
+		t.Fatalf("expected the key to parse as a single value actual=%+v", result)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Key != "PRIVATE_KEY" {
+		t.Fatalf("expected=[PRIVATE_KEY] actual=%+v", result.Different)
+	}
+
+	if lines := strings.Count(result.Different[0].Working, "\n"); lines != 2 {
+		t.Fatalf("expected a value of 3 lines actual=%q", result.Different[0].Working)
+	}
+
+	// an unclosed quote doesn't swallow the lines after it
+	env, malformed := envAnalyzer{}.parse([]string{`KEY="open`, "NAME=app"})
+	if len(malformed) != 1 || len(env) != 1 || env[0].Key != "NAME" {
+		t.Fatalf("expected one malformed line and NAME actual=%+v %+v", malformed, env)
+	}
+}
+
+func TestEnvValueEquals(t *testing.T) {
+	// only the first "=" of a line separates the key from its value
+	env, malformed := envAnalyzer{}.parse([]string{"TOKEN=c2VjcmV0PQ==", "URL=a=b=c"})
+	if len(malformed) != 0 || len(env) != 2 {
+		t.Fatalf("expected two keys actual=%+v %+v", env, malformed)
+	}
+
+	if env[0].Value != "c2VjcmV0PQ==" || env[1].Value != "a=b=c" {
+		t.Fatalf("expected=[c2VjcmV0PQ== a=b=c] actual=[%s %s]", env[0].Value, env[1].Value)
+	}
+
+	c := Config{
+		WorkingPath:    "test/equals/working.env",
+		MasterPath:     "test/equals/master.env",
+		RedactPatterns: []string{},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Malformed) != 0 || len(result.Missing) != 0 || len(result.Extra) != 0 {
+		t.Fatalf("expected the values to parse in full actual=%+v", result)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Working != "host=db;port=5433" {
+		t.Fatalf("expected=[DSN] actual=%+v", result.Different)
+	}
+}
+
+func TestEnvCompareComments(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/comments/working.env",
+		MasterPath:  "test/comments/master.env",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.CommentChanged) != 0 {
+		t.Fatalf("expected comments to be ignored actual=%+v", result.CommentChanged)
+	}
+
+	c.CompareComments = true
+
+	result, err = Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// LOG_LEVEL's comment only differs by its spacing
+	expected := []DiffEntry{
+		{Key: "PORT", Master: "port the http server listens on\nmust be above 1024", Working: "port the http server listens on"},
+		{Key: "DEBUG", Master: "", Working: "enables debug endpoints"},
+	}
+
+	if !reflect.DeepEqual(result.CommentChanged, expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.CommentChanged)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Key != "LOG_LEVEL" {
+		t.Fatalf("expected=[LOG_LEVEL] actual=%+v", result.Different)
+	}
+}
@@ -0,0 +1,98 @@
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// invalidEnvChars matches characters that can't appear in a shell variable name
+var invalidEnvChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// PrintExports writes an `export KEY='value'` line to w for each key missing
+// from the working file, using its master value, so the missing keys can be
+// set in the current shell with eval "$(...)". nested json keys are flattened
+// to env style keys, e.g. db.host becomes DB_HOST, joined by
+// Config.EnvSeparator when set
+func PrintExports(c Config, w io.Writer) error {
+	base, err := newAnalyzer(c)
+	if err != nil {
+		return err
+	}
+
+	missing := map[string]string{}
+
+	if c.format().nested() {
+		j, err := loadJsonAnalyzer(base)
+		if err != nil {
+			return err
+		}
+
+		master, working := map[string]string{}, map[string]string{}
+		sep := c.keySeparator()
+		flattenValues(j.jsonMaster, "", sep, master)
+		flattenValues(j.jsonWorking, "", sep, working)
+
+		separator := c.EnvSeparator
+		if separator == "" {
+			separator = "_"
+		}
+
+		for path, value := range master {
+			if _, ok := working[path]; !ok {
+				missing[strings.ToUpper(strings.Join(splitPath(path, sep), separator))] = value
+			}
+		}
+	} else {
+		result, err := base.scan(FormatEnv)
+		if err != nil {
+			return err
+		}
+
+		master, err := envValues(base.master)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range result.Missing {
+			missing[key] = master[key]
+		}
+	}
+
+	keys := []string{}
+	for key := range missing {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", invalidEnvChars.ReplaceAllString(key, "_"),
+			shellQuote(missing[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flattenValues stores the value of every leaf of a map, keyed by its path
+// joined by sep, in values. arrays are stored as their json text
+func flattenValues(m map[string]interface{}, prefix, sep string, values map[string]string) {
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenValues(nested, joinKey(prefix, k, sep)+sep, sep, values)
+			continue
+		}
+
+		values[joinKey(prefix, k, sep)] = rawJson(v)
+	}
+}
+
+// shellQuote single quotes a value for the shell, escaping any single quotes
+// within it
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,48 @@
+package cfg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintExportsEnv(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/i.env",
+		MasterPath:  "test/j.env",
+	}
+
+	var buf bytes.Buffer
+	if err := PrintExports(c, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "export NAME='cfg, \"the\" analyzer'\n"
+
+	if buf.String() != expected {
+		t.Fatalf("expected=%s actual=%s", expected, buf.String())
+	}
+}
+
+func TestPrintExportsJson(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/transform/working.json",
+		MasterPath:  "test/transform/master.json",
+	}
+
+	var buf bytes.Buffer
+	if err := PrintExports(c, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "export APP_NAME='cfg'\nexport DB_MAX_CONNS='20'\n"
+
+	if buf.String() != expected {
+		t.Fatalf("expected=%s actual=%s", expected, buf.String())
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if actual, expected := shellQuote("it's"), `'it'\''s'`; actual != expected {
+		t.Fatalf("expected=%s actual=%s", expected, actual)
+	}
+}
@@ -0,0 +1,139 @@
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format identifies a supported type of config file
+type Format string
+
+const (
+	FormatEnv  Format = "env"
+	FormatJson Format = "json"
+
+	// FormatDir is a directory of key-per-file secrets, as mounted by docker
+	// or kubernetes. it is read into the format of the file it is compared to
+	FormatDir Format = "dir"
+
+	// FormatURLQuery is a single url-encoded query string, a=1&b=2, compared
+	// like an env file. the values of a repeated key are joined by ","
+	FormatURLQuery Format = "urlquery"
+
+	// FormatYaml is converted to json as it is read, so it is compared the
+	// same way, with aliases and merge keys resolved
+	FormatYaml Format = "yaml"
+
+	// FormatToml is converted to json as it is read, each table of an array
+	// of tables keyed by its index
+	FormatToml Format = "toml"
+
+	// FormatIni is converted to json as it is read, each [section] an object
+	// of its keys
+	FormatIni Format = "ini"
+
+	// FormatProperties is a java .properties file, converted to json as it is
+	// read with its dotted keys as nested objects
+	FormatProperties Format = "properties"
+
+	// FormatXml is converted to json as it is read, keyed by element path
+	// with attributes prefixed by @
+	FormatXml Format = "xml"
+
+	// FormatHcl is converted to json as it is read, e.g. terraform.tfvars,
+	// with blocks keyed by their type and labels
+	FormatHcl Format = "hcl"
+)
+
+// conventions holds the conventional working and master file names for each
+// format, used by DiscoverPair
+var conventions = map[Format][2]string{
+	FormatEnv:        {".env", ".env.example"},
+	FormatJson:       {"config.json", "config.example.json"},
+	FormatYaml:       {"config.yaml", "config.example.yaml"},
+	FormatToml:       {"config.toml", "config.example.toml"},
+	FormatIni:        {"config.ini", "config.example.ini"},
+	FormatProperties: {"application.properties", "application.example.properties"},
+	FormatXml:        {"config.xml", "config.example.xml"},
+	FormatHcl:        {"terraform.tfvars", "terraform.tfvars.example"},
+}
+
+// nested determines if files of the format hold nested keys, compared as
+// json
+func (f Format) nested() bool {
+	switch f {
+	case FormatJson, FormatYaml, FormatToml, FormatIni, FormatProperties, FormatXml, FormatHcl:
+		return true
+	}
+
+	return f.parser() != nil
+}
+
+// builtin determines if the format is one of the package's own
+func (f Format) builtin() bool {
+	switch f {
+	case FormatEnv, FormatJson, FormatDir, FormatURLQuery, FormatYaml, FormatToml, FormatIni, FormatProperties, FormatXml, FormatHcl:
+		return true
+	}
+
+	return false
+}
+
+// formatOf determines the format of a config file from its extension,
+// including those of registered formats, defaulting to env. a trailing .gz
+// extension is ignored
+func formatOf(path string) Format {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return FormatDir
+	}
+
+	ext := filepath.Ext(strings.TrimSuffix(path, ".gz"))
+
+	switch ext {
+	case ".json":
+		return FormatJson
+	case ".query":
+		return FormatURLQuery
+	case ".yaml", ".yml":
+		return FormatYaml
+	case ".toml":
+		return FormatToml
+	case ".ini":
+		return FormatIni
+	case ".properties":
+		return FormatProperties
+	case ".xml":
+		return FormatXml
+	case ".hcl", ".tfvars":
+		return FormatHcl
+	}
+
+	if f, ok := registeredExtension(ext); ok {
+		return f
+	}
+
+	return FormatEnv
+}
+
+// DiscoverPair looks within dir for the conventional working and master file
+// names of the given format, e.g. .env and .env.example or config.json and
+// config.example.json, returning both paths if they exist
+func DiscoverPair(dir string, format Format) (workingPath, masterPath string, err error) {
+	names, ok := conventions[format]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported format %s", format)
+	}
+
+	workingPath = filepath.Join(dir, names[0])
+	masterPath = filepath.Join(dir, names[1])
+
+	for _, path := range []string{workingPath, masterPath} {
+		if _, err := os.Stat(path); err != nil {
+			return "", "", fmt.Errorf("could not find %s. %s", path, err)
+		}
+	}
+
+	return workingPath, masterPath, nil
+}
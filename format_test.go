@@ -0,0 +1,59 @@
+package cfg
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Format
+	}{
+		{"test/a.env", FormatEnv},
+		{".env.example", FormatEnv},
+		{"test/a.json", FormatJson},
+		{"test/urlquery/master.query", FormatURLQuery},
+		{"test/yaml/master.yaml", FormatYaml},
+		{"test/yaml/working.yml", FormatYaml},
+		{"test/toml/master.toml", FormatToml},
+	}
+
+	for _, tt := range tests {
+		if actual := formatOf(tt.path); actual != tt.expected {
+			t.Fatalf("path=%s expected=%s actual=%s", tt.path, tt.expected, actual)
+		}
+	}
+}
+
+func TestDiscoverPair(t *testing.T) {
+	working, master, err := DiscoverPair("test/discover", FormatEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if working != filepath.Join("test/discover", ".env") {
+		t.Fatalf("expected=%s actual=%s", "test/discover/.env", working)
+	}
+
+	if master != filepath.Join("test/discover", ".env.example") {
+		t.Fatalf("expected=%s actual=%s", "test/discover/.env.example", master)
+	}
+
+	// config.example.json does not exist
+	if _, _, err := DiscoverPair("test/discover", FormatJson); err == nil {
+		t.Fatal("expected an error for a missing master file")
+	}
+}
+
+func TestConfigFormat(t *testing.T) {
+	c := Config{WorkingPath: "test/a.json"}
+	if c.format() != FormatJson {
+		t.Fatalf("expected=%s actual=%s", FormatJson, c.format())
+	}
+
+	c.Format = FormatEnv
+	if c.format() != FormatEnv {
+		t.Fatalf("expected=%s actual=%s", FormatEnv, c.format())
+	}
+}
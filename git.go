@@ -0,0 +1,31 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitShow reads the contents of a file as committed at the given git ref. a
+// relative path is resolved against the current directory rather than the
+// repository root, matching how it would be read from disk. a ref beginning
+// with - is rejected, as git would parse it as an option
+func gitShow(ref, path string) ([]byte, error) {
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %s", ref)
+	}
+
+	if !filepath.IsAbs(path) && !strings.HasPrefix(path, "./") {
+		path = "./" + path
+	}
+
+	out, err := exec.Command("git", "show", fmt.Sprintf("%s:%s", ref, path)).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		// surface git's own message, e.g. the ref or path doesn't exist
+		return nil, errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+	}
+
+	return out, err
+}
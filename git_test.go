@@ -0,0 +1,100 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// gitRepo creates a temporary git repository with a committed .env.example,
+// changing into it for the duration of the test
+func gitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	master, err := ioutil.ReadFile(filepath.Join(wd, "test/b.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	if err := ioutil.WriteFile(".env.example", master, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", ".env.example"},
+		{"-c", "user.name=cfg", "-c", "user.email=cfg@example.com", "commit", "-q", "-m", "master"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+
+	return wd
+}
+
+func TestGitShow(t *testing.T) {
+	gitRepo(t)
+
+	// uncommitted changes must not be read
+	if err := ioutil.WriteFile(".env.example", []byte("FRUIT=Mango\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	master, err := gitShow("main", ".env.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(master) == len("FRUIT=Mango\n") {
+		t.Fatal("expected the committed contents of .env.example")
+	}
+
+	if _, err := gitShow("main", "missing.env"); err == nil {
+		t.Fatal("expected an error for a path that doesn't exist")
+	}
+
+	if _, err := gitShow("no-such-ref", ".env.example"); err == nil {
+		t.Fatal("expected an error for a ref that doesn't exist")
+	}
+
+	// would otherwise write the output of git show to a file named main
+	if _, err := gitShow("--output=main", ".env.example"); err == nil {
+		t.Fatal("expected an error for a ref that is an option")
+	}
+
+	if _, err := os.Stat("main"); err == nil {
+		t.Fatal("expected the ref not to be passed to git")
+	}
+}
+
+func TestScanEnvGitRef(t *testing.T) {
+	wd := gitRepo(t)
+
+	c := Config{
+		WorkingPath:  filepath.Join(wd, "test/a.env"),
+		MasterPath:   ".env.example",
+		MasterGitRef: "main",
+	}
+
+	keys, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected=%d actual=%d", 3, len(keys))
+	}
+}
@@ -0,0 +1,38 @@
+package cfg
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// NewDriftHandler returns an http.Handler that scans the config on each
+// request and responds with the Result as JSON. the status code is 200 when
+// the working file is clean, 409 when it has drifted from the master and 500
+// when the scan fails
+func NewDriftHandler(c Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		result, err := Scan(c)
+		if err != nil {
+			c.logger().Error("drift scan failed", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		status := http.StatusOK
+		if result.drifted() {
+			status = http.StatusConflict
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// drifted determines whether the Result has any missing, different or
+// forbidden keys
+func (r *Result) drifted() bool {
+	return len(r.Missing) > 0 || len(r.Different) > 0 || len(r.Forbidden) > 0
+}
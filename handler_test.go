@@ -0,0 +1,48 @@
+package cfg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDriftHandler(t *testing.T) {
+	tests := []struct {
+		working string
+		master  string
+		status  int
+	}{
+		{"test/a.env", "test/a.env", http.StatusOK},
+		{"test/a.env", "test/b.env", http.StatusConflict},
+		{"test/a.env", "test/missing.env", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		handler := NewDriftHandler(Config{WorkingPath: tt.working, MasterPath: tt.master, AllowSamePath: true})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/config-drift", nil))
+
+		if rec.Code != tt.status {
+			t.Fatalf("expected=%d actual=%d", tt.status, rec.Code)
+		}
+
+		if rec.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("expected a json response actual=%s", rec.Header().Get("Content-Type"))
+		}
+
+		if tt.status == http.StatusInternalServerError {
+			continue
+		}
+
+		var result Result
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+
+		if result.WorkingPath != tt.working {
+			t.Fatalf("expected=%s actual=%s", tt.working, result.WorkingPath)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ScanHcl will scan two hcl configuration files, e.g. terraform.tfvars or
+// nomad and consul configs, returning a slice of keys, as dotted paths, that
+// exist in the master file and are missing in the working file. a block is
+// keyed by its type then each of its labels, e.g. job.web.group.api
+func ScanHcl(c Config) ([]string, error) {
+	c.Format = FormatHcl
+	return ScanJson(c)
+}
+
+// ScanHclResult will scan two hcl configuration files returning a Result
+// holding every missing, extra and different key
+func ScanHclResult(c Config) (*Result, error) {
+	c.Format = FormatHcl
+	return Scan(c)
+}
+
+// PrintHcl uses ScanHcl to retrieve a slice of missing keys and will then
+// print out the difference / discrepencies between the master and working files
+func PrintHcl(c Config) error {
+	c.Format = FormatHcl
+	return PrintJson(c)
+}
+
+// hclToJson converts a hcl document to json, keeping the order of its
+// attributes and blocks. constant expressions are written as their value and
+// any other, e.g. one referencing a variable, as its source text. blocks with
+// the same type and labels are keyed by their index
+func hclToJson(b []byte) ([]byte, error) {
+	f, diags := hclsyntax.ParseConfig(b, "", hcl.InitialPos)
+	for _, d := range diags {
+		if d.Severity != hcl.DiagError {
+			continue
+		}
+
+		if d.Subject == nil {
+			return nil, fmt.Errorf("%s. %s", d.Summary, d.Detail)
+		}
+
+		return nil, fmt.Errorf("line %d: %s. %s", d.Subject.Start.Line, d.Summary, d.Detail)
+	}
+
+	w := hclWriter{src: b}
+	if err := w.body(f.Body.(*hclsyntax.Body)); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// hclTree holds the attribute values and blocks of a body by key, in the
+// order the keys appear
+type hclTree struct {
+	keys   []string
+	values map[string]interface{}
+
+	// bodies are the blocks whose type and labels end at this tree
+	bodies []*hclsyntax.Body
+}
+
+// child returns the tree held by key, adding it if key isn't set
+func (t *hclTree) child(key string) (*hclTree, error) {
+	switch v := t.values[key].(type) {
+	case *hclTree:
+		return v, nil
+	case json.RawMessage:
+		return nil, fmt.Errorf("%s is both an attribute and a block", key)
+	}
+
+	child := &hclTree{values: map[string]interface{}{}}
+	t.keys = append(t.keys, key)
+	t.values[key] = child
+
+	return child, nil
+}
+
+// hclWriter writes hcl bodies as json
+type hclWriter struct {
+	buf bytes.Buffer
+	src []byte
+}
+
+// body writes a body's attributes and blocks as a json object
+func (w *hclWriter) body(body *hclsyntax.Body) error {
+	tree := &hclTree{values: map[string]interface{}{}}
+
+	attrs := []*hclsyntax.Attribute{}
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+
+	for _, attr := range attrs {
+		tree.keys = append(tree.keys, attr.Name)
+		tree.values[attr.Name] = w.value(attr.Expr)
+	}
+
+	for _, block := range body.Blocks {
+		node, err := tree.child(block.Type)
+		if err != nil {
+			return fmt.Errorf("line %d: %s", block.TypeRange.Start.Line, err)
+		}
+
+		for _, label := range block.Labels {
+			if node, err = node.child(label); err != nil {
+				return fmt.Errorf("line %d: %s", block.TypeRange.Start.Line, err)
+			}
+		}
+
+		node.bodies = append(node.bodies, block.Body)
+	}
+
+	return w.tree(tree)
+}
+
+// tree writes a tree of attributes and blocks as a json object
+func (w *hclWriter) tree(t *hclTree) error {
+	w.buf.WriteByte('{')
+	for i, k := range t.keys {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(k)
+		w.buf.Write(key)
+		w.buf.WriteByte(':')
+
+		var err error
+		switch v := t.values[k].(type) {
+		case json.RawMessage:
+			w.buf.Write(v)
+		case *hclTree:
+			err = w.blocks(k, v)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	w.buf.WriteByte('}')
+
+	return nil
+}
+
+// blocks writes the blocks of a key, a single block as its body and several
+// as an object keyed by their index
+func (w *hclWriter) blocks(key string, t *hclTree) error {
+	switch {
+	case len(t.bodies) == 0:
+		return w.tree(t)
+
+	case len(t.keys) > 0:
+		return fmt.Errorf("%s blocks are both labelled and unlabelled", key)
+
+	case len(t.bodies) == 1:
+		return w.body(t.bodies[0])
+	}
+
+	w.buf.WriteByte('{')
+	for i, body := range t.bodies {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+
+		w.buf.WriteString(strconv.Quote(strconv.Itoa(i)))
+		w.buf.WriteByte(':')
+
+		if err := w.body(body); err != nil {
+			return err
+		}
+	}
+	w.buf.WriteByte('}')
+
+	return nil
+}
+
+// value returns the json of an expression's value, or of its source text if
+// it can't be evaluated without variables or functions
+func (w *hclWriter) value(expr hclsyntax.Expression) json.RawMessage {
+	if v, diags := expr.Value(nil); !diags.HasErrors() && v.IsWhollyKnown() {
+		if b, err := ctyjson.Marshal(v, v.Type()); err == nil {
+			return b
+		}
+	}
+
+	b, _ := json.Marshal(string(expr.Range().SliceBytes(w.src)))
+	return b
+}
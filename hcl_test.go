@@ -0,0 +1,76 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScanHcl(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/hcl/terraform.tfvars",
+		MasterPath:  "test/hcl/terraform.tfvars.example",
+	}
+
+	missing, err := ScanHcl(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(missing)
+	if expected := []string{"replicas", "tags.service"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+
+	// the format is detected from the working file
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	different := []string{}
+	for _, d := range result.Different {
+		different = append(different, d.String())
+	}
+
+	if expected := []string{"instance_type=m5.large"}; !reflect.DeepEqual(different, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, different)
+	}
+}
+
+func TestHclToJson(t *testing.T) {
+	tests := []struct {
+		hcl      string
+		expected string
+	}{
+		{"", `{}`},
+		{"b = 1\na = \"two\"\nc = [true, null]", `{"b":1,"a":"two","c":[true,null]}`},
+		{"a = var.region\nb = \"${var.x}-y\"\nc = upper(\"x\")", `{"a":"var.region","b":"\"${var.x}-y\"","c":"upper(\"x\")"}`},
+		{"job \"web\" {\n  group \"api\" {\n    count = 2\n  }\n}", `{"job":{"web":{"group":{"api":{"count":2}}}}}`},
+		{"variable \"a\" {}\nvariable \"b\" {\n  default = 1\n}", `{"variable":{"a":{},"b":{"default":1}}}`},
+		// repeated blocks are keyed by index
+		{"service {\n  port = 80\n}\nservice {\n  port = 443\n}", `{"service":{"0":{"port":80},"1":{"port":443}}}`},
+		{"a = 1\na {}", ""},
+		{"a {}\na \"x\" {}", ""},
+		{"a = ", ""},
+	}
+
+	for _, tt := range tests {
+		b, err := hclToJson([]byte(tt.hcl))
+
+		if tt.expected == "" {
+			if err == nil {
+				t.Fatalf("hcl=%q expected an error actual=%s", tt.hcl, b)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("hcl=%q %s", tt.hcl, err)
+		}
+
+		if string(b) != tt.expected {
+			t.Fatalf("hcl=%q expected=%s actual=%s", tt.hcl, tt.expected, b)
+		}
+	}
+}
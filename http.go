@@ -0,0 +1,160 @@
+package cfg
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultHttpTimeout is used when no Config.HTTPTimeout is provided
+const defaultHttpTimeout = 30 * time.Second
+
+// errMaxBytes is returned when a fetched master file is larger than
+// Config.MaxBytes
+var errMaxBytes = errors.New("master exceeds max size")
+
+// httpFetcher holds data for fetching a remote master file over http(s)
+type httpFetcher struct {
+	client   *http.Client
+	maxBytes int64
+	headers  map[string]string
+}
+
+// newHttpFetcher returns a new httpFetcher. requests go through proxy when
+// given, otherwise through the proxy set by the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables. tlsConfig, when given, configures client
+// certificates and trusted CAs. headers are set on every request
+func newHttpFetcher(timeout time.Duration, maxBytes int64, proxy *url.URL, tlsConfig *tls.Config, headers map[string]string) *httpFetcher {
+	if timeout <= 0 {
+		timeout = defaultHttpTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &httpFetcher{
+		client:   &http.Client{Timeout: timeout, Transport: transport},
+		maxBytes: maxBytes,
+		headers:  headers,
+	}
+}
+
+// tlsConfig returns the tls config for fetching a master over https with
+// Config.ClientCert, ClientKey and CACert, or nil if none are set so the
+// system trust store is used
+func (c Config) tlsConfig() (*tls.Config, error) {
+	if c.ClientCert == "" && c.ClientKey == "" && c.CACert == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		certPEM, err := pemOrFile(c.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+
+		keyPEM, err := pemOrFile(c.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate. %s", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CACert != "" {
+		caPEM, err := pemOrFile(c.CACert)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("could not load CA certificate. no certificates found")
+		}
+
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// pemOrFile returns PEM encoded bytes given either inline or as the path of
+// a file holding them
+func pemOrFile(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	b, err := ioutil.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s. %s", value, err)
+	}
+
+	return b, nil
+}
+
+// isUrl determines if the path passed in is a http(s) url
+func isUrl(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// get fetches the body of the given url, abandoning the request if ctx is
+// done first. the body is read through a LimitReader so an enormous response
+// can't exhaust memory
+func (h httpFetcher) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	if h.maxBytes <= 0 {
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	// read one byte past the cap so we can tell an exact fit from an overflow
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, h.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > h.maxBytes {
+		return nil, errMaxBytes
+	}
+
+	return body, nil
+}
@@ -0,0 +1,197 @@
+package cfg
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsUrl(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"https://config.example.com/.env", true},
+		{"http://config.example.com/.env", true},
+		{"test/a.env", false},
+		{"/home/ubuntu/app/config.json", false},
+	}
+
+	for _, tt := range tests {
+		if actual := isUrl(tt.path); actual != tt.expected {
+			t.Fatalf("path=%s expected=%t actual=%t", tt.path, tt.expected, actual)
+		}
+	}
+}
+
+func TestHttpGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("FRUIT=Mango"))
+	}))
+	defer server.Close()
+
+	body, err := newHttpFetcher(0, 11, nil, nil, nil).get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "FRUIT=Mango" {
+		t.Fatalf("expected=%s actual=%s", "FRUIT=Mango", body)
+	}
+}
+
+func TestHttpGetHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("FRUIT=Mango"))
+	}))
+	defer server.Close()
+
+	if _, err := newHttpFetcher(0, 0, nil, nil, nil).get(context.Background(), server.URL); err == nil {
+		t.Fatal("expected an error without the token")
+	}
+
+	missing, err := ScanEnv(Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  server.URL,
+		HTTPHeaders: map[string]string{"Authorization": "Bearer token"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(missing) != 0 {
+		t.Fatalf("expected no missing keys actual=%v", missing)
+	}
+}
+
+func TestHttpMaxBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("FRUIT=Mango"))
+	}))
+	defer server.Close()
+
+	if _, err := newHttpFetcher(0, 5, nil, nil, nil).get(context.Background(), server.URL); err != errMaxBytes {
+		t.Fatalf("expected=%s actual=%v", errMaxBytes, err)
+	}
+}
+
+func TestHttpTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	if _, err := newHttpFetcher(10*time.Millisecond, 0, nil, nil, nil).get(context.Background(), server.URL); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+}
+
+func TestHttpGetProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("HOST=" + r.URL.Host))
+	}))
+	defer proxy.Close()
+
+	u, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := newHttpFetcher(0, 0, u, nil, nil).get(context.Background(), "http://config.example.com/.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "HOST=config.example.com"; string(body) != expected {
+		t.Fatalf("expected=%s actual=%s", expected, body)
+	}
+}
+
+// clientCertificate returns a self-signed client certificate and its key,
+// PEM encoded
+func clientCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cfganalyze"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestScanEnvHttpMutualTLS(t *testing.T) {
+	certPEM, keyPEM := clientCertificate(t)
+
+	clients := x509.NewCertPool()
+	clients.AppendCertsFromPEM(certPEM)
+
+	server := httptest.NewUnstartedServer(http.FileServer(http.Dir("test")))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clients}
+	server.StartTLS()
+	defer server.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// the key is given as a file and the certificates inline
+	keyPath := filepath.Join(t.TempDir(), "client.key")
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  server.URL + "/b.env",
+		ClientCert:  string(certPEM),
+		ClientKey:   keyPath,
+		CACert:      string(caPEM),
+	}
+
+	keys, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected=%d actual=%d", 3, len(keys))
+	}
+
+	c.ClientCert, c.ClientKey = "", ""
+	if _, err := ScanEnv(c); err == nil {
+		t.Fatal("expected the server to require a client certificate")
+	}
+}
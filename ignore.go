@@ -0,0 +1,115 @@
+package cfg
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// keyFilter matches keys against Config.Ignore, nested keys as paths joined
+// by sep
+type keyFilter struct {
+	globs []string
+	res   []*regexp.Regexp
+	sep   string
+}
+
+// newKeyFilter compiles the patterns of Config.Ignore. a pattern wrapped in
+// slashes, e.g. /^AWS_/, is a regular expression and any other a glob
+func newKeyFilter(patterns []string, sep string) (*keyFilter, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	f := keyFilter{sep: sep}
+
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid ignore pattern %s. %s", pattern, err)
+			}
+			f.res = append(f.res, re)
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %s. %s", pattern, err)
+		}
+		f.globs = append(f.globs, pattern)
+	}
+
+	return &f, nil
+}
+
+// ignored determines if a key matches one of the patterns
+func (f *keyFilter) ignored(key string) bool {
+	if f == nil {
+		return false
+	}
+
+	for _, re := range f.res {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+
+	return matchPath(f.globs, key, f.sep)
+}
+
+// env returns the pairs whose keys aren't ignored
+func (f *keyFilter) env(env []configEnv) []configEnv {
+	if f == nil {
+		return env
+	}
+
+	kept := []configEnv{}
+	for _, e := range env {
+		if !f.ignored(e.Key) {
+			kept = append(kept, e)
+		}
+	}
+
+	return kept
+}
+
+// json returns a copy of a json map without the ignored keys, matched as
+// dotted paths joined by sep. the objects within arrays are filtered too,
+// their keys prefixed by their index, e.g. servers.0.secret
+func (f *keyFilter) json(m map[string]interface{}, prefix, sep string) map[string]interface{} {
+	if f == nil {
+		return m
+	}
+
+	kept := map[string]interface{}{}
+
+	for k, v := range m {
+		path := joinKey(prefix, k, sep)
+		if f.ignored(path) {
+			continue
+		}
+
+		kept[k] = f.jsonValue(v, path, sep)
+	}
+
+	return kept
+}
+
+// jsonValue filters the objects within a json value at path
+func (f *keyFilter) jsonValue(v interface{}, path, sep string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return f.json(v, path+sep, sep)
+
+	case []interface{}:
+		kept := make([]interface{}, len(v))
+		for i, item := range v {
+			kept[i] = f.jsonValue(item, path+sep+strconv.Itoa(i), sep)
+		}
+		return kept
+	}
+
+	return v
+}
@@ -0,0 +1,58 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScanIgnore(t *testing.T) {
+	tests := []struct {
+		working, master string
+		ignore          []string
+		missing         []string
+		different       []string
+	}{
+		{"test/ignore/working.env", "test/ignore/master.env", nil, []string{"AWS_SECRET"}, []string{"AWS_KEY", "DB_HOST"}},
+		{"test/ignore/working.env", "test/ignore/master.env", []string{"AWS_*"}, []string{}, []string{"DB_HOST"}},
+		{"test/ignore/working.env", "test/ignore/master.env", []string{"/^(AWS|DB)_/"}, []string{}, []string{}},
+		{"test/ignore/working.json", "test/ignore/master.json", nil, []string{"port", "secrets.key"}, []string{"secrets.token", "servers"}},
+		{"test/ignore/working.json", "test/ignore/master.json", []string{"secrets"}, []string{"port"}, []string{"servers"}},
+		{"test/ignore/working.json", "test/ignore/master.json", []string{"secrets.*", "servers.*.password"}, []string{"port"}, []string{}},
+		{"test/ignore/working.json", "test/ignore/master.json", []string{"/^(port|secrets)$/"}, []string{}, []string{"servers"}},
+	}
+
+	for _, tt := range tests {
+		r, err := Scan(Config{WorkingPath: tt.working, MasterPath: tt.master, Ignore: tt.ignore})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		different := []string{}
+		for _, d := range r.Different {
+			different = append(different, d.Key)
+		}
+
+		sort.Strings(r.Missing)
+		sort.Strings(different)
+
+		if len(r.Missing) != 0 || len(tt.missing) != 0 {
+			if !reflect.DeepEqual(r.Missing, tt.missing) {
+				t.Errorf("ignore=%v expected missing=%v actual=%v", tt.ignore, tt.missing, r.Missing)
+			}
+		}
+
+		if !reflect.DeepEqual(different, tt.different) {
+			t.Errorf("ignore=%v expected different=%v actual=%v", tt.ignore, tt.different, different)
+		}
+	}
+}
+
+func TestScanIgnoreInvalid(t *testing.T) {
+	for _, pattern := range []string{"[", "/(/"} {
+		_, err := Scan(Config{WorkingPath: "test/ignore/working.env", MasterPath: "test/ignore/master.env", Ignore: []string{pattern}})
+		if err == nil {
+			t.Errorf("pattern=%s expected an error", pattern)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ScanIni will scan two .ini configuration files returning a slice of keys,
+// as section.key paths, that exist in the master file and are missing in the
+// working file. keys before the first section are top level keys
+func ScanIni(c Config) ([]string, error) {
+	c.Format = FormatIni
+	return ScanJson(c)
+}
+
+// ScanIniResult will scan two .ini configuration files returning a Result
+// holding every missing, extra and different key
+func ScanIniResult(c Config) (*Result, error) {
+	c.Format = FormatIni
+	return Scan(c)
+}
+
+// PrintIni uses ScanIni to retrieve a slice of missing keys and will then
+// print out the difference / discrepencies between the master and working files
+func PrintIni(c Config) error {
+	c.Format = FormatIni
+	return PrintJson(c)
+}
+
+// iniToJson converts an ini document to json, keeping the order of its keys.
+// each [section] becomes an object of its keys, and every value a string.
+// lines starting with ; or # are comments and a line ending in a backslash
+// continues on the next. a repeated section is merged, and of a repeated key
+// the last value wins
+func iniToJson(b []byte) ([]byte, error) {
+	root := newKvTree()
+	section := root
+
+	lines := logicalLines(b, func(line string) bool {
+		return !iniComment(line) && strings.HasSuffix(line, `\`)
+	})
+
+	for _, l := range lines {
+		line := strings.TrimSpace(l.text)
+
+		switch {
+		case line == "" || iniComment(line):
+			continue
+
+		case line[0] == '[':
+			if !strings.HasSuffix(line, "]") || strings.TrimSpace(line[1:len(line)-1]) == "" {
+				return nil, fmt.Errorf("line %d: invalid section %s", l.number, line)
+			}
+
+			var err error
+			if section, err = root.child(strings.TrimSpace(line[1 : len(line)-1])); err != nil {
+				return nil, fmt.Errorf("line %d: %s", l.number, err)
+			}
+			continue
+		}
+
+		// a key without a value, e.g. my.cnf's skip-networking, is empty
+		key, value := line, ""
+		if i := strings.IndexAny(line, "=:"); i >= 0 {
+			key, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", l.number)
+		}
+
+		if err := section.set(key, unquote(value)); err != nil {
+			return nil, fmt.Errorf("line %d: %s", l.number, err)
+		}
+	}
+
+	return root.json(), nil
+}
+
+// iniComment determines if a line is a comment
+func iniComment(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#")
+}
+
+// unquote strips the matching quotes wrapping a value, if any
+func unquote(value string) string {
+	if len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// logicalLine is a line of a file joined with the lines it continues onto,
+// numbered by the line it starts on
+type logicalLine struct {
+	text   string
+	number int
+}
+
+// logicalLines splits b into lines, joining each line that continues with
+// the next, without the continuing backslash and the next line's indent
+func logicalLines(b []byte, continues func(line string) bool) []logicalLine {
+	lines := []logicalLine{}
+
+	var current *logicalLine
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if current != nil {
+			current.text += strings.TrimLeft(line, " \t\f")
+		} else {
+			lines = append(lines, logicalLine{text: line, number: i + 1})
+			current = &lines[len(lines)-1]
+		}
+
+		if continues(current.text) {
+			current.text = current.text[:len(current.text)-1]
+		} else {
+			current = nil
+		}
+	}
+
+	return lines
+}
+
+// kvTree holds string values and nested trees by key, in the order the keys
+// were first set
+type kvTree struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// newKvTree returns an empty kvTree
+func newKvTree() *kvTree {
+	return &kvTree{values: map[string]interface{}{}}
+}
+
+// set sets the value of key, keeping its position if already set
+func (t *kvTree) set(key, value string) error {
+	switch t.values[key].(type) {
+	case nil:
+		t.keys = append(t.keys, key)
+	case *kvTree:
+		return fmt.Errorf("%s is both a value and holds keys", key)
+	}
+
+	t.values[key] = value
+	return nil
+}
+
+// child returns the tree held by key, adding it if key isn't set
+func (t *kvTree) child(key string) (*kvTree, error) {
+	switch v := t.values[key].(type) {
+	case *kvTree:
+		return v, nil
+	case string:
+		return nil, fmt.Errorf("%s is both a value and holds keys", key)
+	}
+
+	child := newKvTree()
+	t.keys = append(t.keys, key)
+	t.values[key] = child
+
+	return child, nil
+}
+
+// json returns the tree as a json object
+func (t *kvTree) json() []byte {
+	buf := bytes.Buffer{}
+	t.write(&buf)
+	return buf.Bytes()
+}
+
+// write writes the tree as a json object
+func (t *kvTree) write(buf *bytes.Buffer) {
+	buf.WriteByte('{')
+	for i, k := range t.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		switch v := t.values[k].(type) {
+		case *kvTree:
+			v.write(buf)
+		default:
+			value, _ := json.Marshal(v)
+			buf.Write(value)
+		}
+	}
+	buf.WriteByte('}')
+}
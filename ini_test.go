@@ -0,0 +1,75 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScanIni(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/ini/working.ini",
+		MasterPath:  "test/ini/master.ini",
+	}
+
+	missing, err := ScanIni(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(missing)
+	if expected := []string{"cache", "database.replicas"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	different := []string{}
+	for _, d := range result.Different {
+		different = append(different, d.String())
+	}
+
+	if expected := []string{"database.host=db.internal"}; !reflect.DeepEqual(different, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, different)
+	}
+}
+
+func TestIniToJson(t *testing.T) {
+	tests := []struct {
+		ini      string
+		expected string
+	}{
+		{"", `{}`},
+		{"b = 1\na: two\n[s]\nz = \"quoted\"\ny", `{"b":"1","a":"two","s":{"z":"quoted","y":""}}`},
+		{"; comment \\\n# comment\na = 1 \\\n  2\r\n", `{"a":"1 2"}`},
+		// repeated sections merge and the last value of a key wins
+		{"[s]\na = 1\n[t]\nb = 2\n[s]\na = 3\nc = 4", `{"s":{"a":"3","c":"4"},"t":{"b":"2"}}`},
+		{"[a.b]\nc = 1", `{"a.b":{"c":"1"}}`},
+		{"s = 1\n[s]\na = 1", ""},
+		{"[s\na = 1", ""},
+		{"[]", ""},
+		{"= 1", ""},
+	}
+
+	for _, tt := range tests {
+		b, err := iniToJson([]byte(tt.ini))
+
+		if tt.expected == "" {
+			if err == nil {
+				t.Fatalf("ini=%q expected an error actual=%s", tt.ini, b)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("ini=%q %s", tt.ini, err)
+		}
+
+		if string(b) != tt.expected {
+			t.Fatalf("ini=%q expected=%s actual=%s", tt.ini, tt.expected, b)
+		}
+	}
+}
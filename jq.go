@@ -0,0 +1,50 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// jqTransform applies a jq expression (e.g. "{db: .database}") to a parsed
+// json document, returning the single object it produces. an empty
+// expression returns the document as is
+func jqTransform(m jsoncfg, expression string) (jsoncfg, error) {
+	if expression == "" {
+		return m, nil
+	}
+
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse transform %s. %s", expression, err)
+	}
+
+	iter := query.Run(map[string]interface{}(m))
+
+	v, ok := iter.Next()
+	if !ok {
+		return nil, fmt.Errorf("transform %s produced no output", expression)
+	}
+
+	if err, ok := v.(error); ok {
+		return nil, fmt.Errorf("could not apply transform %s. %s", expression, err)
+	}
+
+	if _, ok := iter.Next(); ok {
+		return nil, fmt.Errorf("transform %s produced more than one output", expression)
+	}
+
+	// round trip so numbers are json.Number again, as when parsed
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	transformed := jsoncfg{}
+	if err := unmarshalJson(b, &transformed); err != nil {
+		return nil, fmt.Errorf("transform %s must produce an object. %s", expression, err)
+	}
+
+	return transformed, nil
+}
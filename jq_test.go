@@ -0,0 +1,38 @@
+package cfg
+
+import "testing"
+
+func TestJqTransform(t *testing.T) {
+	c := Config{
+		WorkingPath:      "test/jq/working.json",
+		MasterPath:       "test/jq/master.json",
+		WorkingTransform: "{db: .database}",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || len(result.Extra) != 0 || len(result.Different) != 0 {
+		t.Fatalf("expected only name to be missing actual=%+v", result)
+	}
+
+	c.MasterTransform = "{db: .db | del(.name)}"
+
+	analyzer, err := newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if equal, _ := analyzer.equality(); !equal {
+		t.Fatal("expected the transformed files to be equal")
+	}
+
+	for _, expression := range []string{".db[", ".db, .db", "empty", ".db.host"} {
+		c.MasterTransform = expression
+		if _, err := Scan(c); err == nil {
+			t.Fatalf("expected an error for %s", expression)
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package cfg
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // jsoncfg is a basic map struct for json configs
@@ -22,13 +30,35 @@ func newJsonAnalyzer(c Config) (*jsonAnalyzer, error) {
 		return nil, err
 	}
 
+	return loadJsonAnalyzer(analyzer)
+}
+
+// loadJsonAnalyzer returns a new jsonAnalyzer loaded with the json maps of
+// the base analyzer's working and master files
+func loadJsonAnalyzer(analyzer *analyzer) (*jsonAnalyzer, error) {
+	c := analyzer.config
+
 	working := jsoncfg{}
-	if err := json.Unmarshal(analyzer.working, &working); err != nil {
+	if err := analyzer.unmarshalWorking(&working); err != nil {
+		analyzer.log.Error("could not parse working file", "path", c.WorkingPath, "error", err)
+		return nil, err
+	}
+
+	working, inWorking, err := analyzer.prepareJson(working, c.WorkingPath, analyzer.workingReal, c.WorkingTransform, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	master := jsoncfg{}
-	if err := json.Unmarshal(analyzer.master, &master); err != nil {
+	master, inMaster, err := analyzer.masterJson()
+	if err != nil {
+		return nil, err
+	}
+
+	if !inWorking && !inMaster {
+		return nil, fmt.Errorf("root path %s not found in %s or %s", c.RootPath, c.WorkingPath, c.MasterPath)
+	}
+
+	if err := analyzer.readDefaults(FormatJson); err != nil {
 		return nil, err
 	}
 
@@ -38,55 +68,623 @@ func newJsonAnalyzer(c Config) (*jsonAnalyzer, error) {
 		jsonMaster:  master,
 	}
 
+	jsonAnalyzer.log.Debug("parsed json config", "working_keys", len(working),
+		"master_keys", len(master))
+
 	return &jsonAnalyzer, nil
 }
 
+// masterJson parses the master file into a json map prepared for comparing,
+// returning whether it holds Config.RootPath. the map is taken from the
+// analyzer's cache, if it has one, when the master and the files it
+// references are unchanged
+func (a *analyzer) masterJson() (jsoncfg, bool, error) {
+	parse := func(refs refFiles) (jsoncfg, bool, error) {
+		master := jsoncfg{}
+		if err := unmarshalFile(a.config, a.master, a.config.MasterPath, &master); err != nil {
+			a.log.Error("could not parse master file", "path", a.config.MasterPath, "error", err)
+			return nil, false, err
+		}
+
+		return a.prepareJson(master, a.config.MasterPath, a.masterReal, a.config.MasterTransform, refs)
+	}
+
+	if a.cache == nil {
+		return parse(nil)
+	}
+
+	return a.cache.json(a.master, parse)
+}
+
+// prepareJson applies Config.ResolveRefs, the jq transform, Config.RootPath,
+// Config.KeyTransform and Config.Ignore to a parsed json file, returning
+// whether it holds the root path. the files references are resolved from
+// are stored in refs, if given
+func (a *analyzer) prepareJson(m jsoncfg, path, real, transform string, refs refFiles) (jsoncfg, bool, error) {
+	c := a.config
+
+	if c.ResolveRefs {
+		if real == "" {
+			real = path
+		}
+
+		v, err := resolveRefs(map[string]interface{}(m), real, refs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		resolved, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("could not resolve %s. a top level reference must be an object", path)
+		}
+
+		m = jsoncfg(resolved)
+	}
+
+	m, err := jqTransform(m, transform)
+	if err != nil {
+		return nil, false, err
+	}
+
+	found := true
+	if c.RootPath != "" {
+		m, found = subtree(m, c.RootPath, c.keySeparator())
+	}
+
+	if c.KeyTransform != nil {
+		m = transformKeys(m, c.KeyTransform)
+	}
+
+	return a.ignoreKeys.json(m, "", c.keySeparator()), found, nil
+}
+
+// subtree returns the nested map at the given path, or an empty map if the
+// path doesn't exist or doesn't hold a map
+func subtree(m jsoncfg, path, sep string) (jsoncfg, bool) {
+	current := map[string]interface{}(m)
+
+	for _, part := range splitPath(path, sep) {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return jsoncfg{}, false
+		}
+		current = next
+	}
+
+	return jsoncfg(current), true
+}
+
+// transformKeys returns a copy of the map with transform applied to every key,
+// drilling down into nested maps
+func transformKeys(m map[string]interface{}, transform func(string) string) jsoncfg {
+	transformed := jsoncfg{}
+
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = map[string]interface{}(transformKeys(nested, transform))
+		}
+		transformed[transform(k)] = v
+	}
+
+	return transformed
+}
+
+// unmarshalWorking unmarshals the working file into a json map
+func (a analyzer) unmarshalWorking(working *jsoncfg) error {
+	return unmarshalFile(a.config, a.working, a.config.WorkingPath, working)
+}
+
+// unmarshalFile unmarshals a file into a json map. a flat env file, compared
+// against a nested one, is un-flattened by Config.EnvSeparator or
+// Config.KeyConvention. it only reads c, as masters are unmarshalled while the
+// working file is still being read
+func unmarshalFile(c Config, b []byte, path string, m *jsoncfg) error {
+	if !c.flattened(path) {
+		return unmarshalJson(b, m)
+	}
+
+	env, err := envAnalyzer{}.unmarshal(strings.Split(string(b), "\n"))
+	if err != nil {
+		return err
+	}
+
+	for _, e := range env {
+		parts := c.unflatten(e.Key)
+
+		m := map[string]interface{}(*m)
+		for _, part := range parts[:len(parts)-1] {
+			// a nested key takes precedence over a scalar of the same name
+			if _, ok := m[part].(map[string]interface{}); !ok {
+				m[part] = map[string]interface{}{}
+			}
+			m = m[part].(map[string]interface{})
+		}
+
+		m[parts[len(parts)-1]] = e.Value
+	}
+
+	return nil
+}
+
+// unmarshalJson unmarshals a json document keeping numbers as json.Number, so
+// their original text (e.g. 1000000 rather than 1e+06) is preserved
+func unmarshalJson(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+
+	return nil
+}
+
 // scan will analyze two sets of json config files identifying keys that
-// exist in the master file and are missing in the working file
+// exist in the master file and are missing in the working file, keys that
+// exist in both files with different values, keys that exist in the working
+// file and are missing in the master file, and keys in the working file that
+// are forbidden
 func (j *jsonAnalyzer) scan() {
-	j.diff(j.jsonWorking, j.jsonMaster)
+	j.diff(j.jsonWorking, j.jsonMaster, "")
+	j.compare(j.jsonWorking, j.jsonMaster, "")
+
+	// diffing with the roles swapped finds the keys missing from master
+	if !j.config.IgnoreExtra {
+		missing, required, optional := j.missing, j.required, j.optional
+		j.missing, j.required, j.optional = nil, nil, nil
+
+		j.diff(j.jsonMaster, j.jsonWorking, "")
+
+		j.extra, j.missing, j.required, j.optional = j.missing, missing, required, optional
+	}
+
+	sort.Strings(j.optional)
+
+	flat := map[string]bool{}
+	j.flatten(j.jsonWorking, "", flat)
+
+	keys := []string{}
+	for k := range flat {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	j.findForbidden(keys)
+
+	if j.config.JSONOrdered && formatOf(j.config.WorkingPath) != FormatEnv {
+		j.findOrderChanged()
+	}
+
+	j.applyDefaults()
+
+	if j.config.PathStyle == PathJSONPointer {
+		j.pointers()
+	}
 }
 
-// diff will peform a diff on keys between two maps, storing ones
-// that exist in the master and are missing in the working file
-func (j *jsonAnalyzer) diff(working jsoncfg, master jsoncfg) {
-	a := j.keys(working, master)
-	b := j.keys(master, working)
+// findOrderChanged stores keys, as dotted paths, present in the same object
+// of both files whose position amongst the keys they have in common differs
+func (j *jsonAnalyzer) findOrderChanged() {
+	masterOrder, workingOrder := map[string][]string{}, map[string][]string{}
 
-	for _, str := range b {
-		if !j.contains(a, str) && !j.contains(j.missing, str) {
-			j.missing = append(j.missing, str)
+	sep := j.config.keySeparator()
+
+	if err := keyOrder(j.master, sep, masterOrder, j.config.KeyTransform); err != nil {
+		j.log.Warn("could not read key order", "path", j.config.MasterPath, "error", err)
+		return
+	}
+
+	if err := keyOrder(j.working, sep, workingOrder, j.config.KeyTransform); err != nil {
+		j.log.Warn("could not read key order", "path", j.config.WorkingPath, "error", err)
+		return
+	}
+
+	if root := j.config.RootPath; root != "" {
+		masterOrder, workingOrder = within(masterOrder, root+sep), within(workingOrder, root+sep)
+	}
+
+	prefixes := []string{}
+	for prefix := range masterOrder {
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		working, ok := workingOrder[prefix]
+		if !ok {
+			continue
+		}
+
+		master := masterOrder[prefix]
+		changed := orderChanged(common(master, working), common(working, master))
+
+		for _, k := range changed {
+			j.orderChanged = append(j.orderChanged, joinKey(prefix, k, sep))
+		}
+	}
+}
+
+// within returns the key orders of the objects within the root path prefix,
+// with their prefixes made relative to it
+func within(order map[string][]string, root string) map[string][]string {
+	relative := map[string][]string{}
+
+	for prefix, keys := range order {
+		if strings.HasPrefix(prefix, root) {
+			relative[strings.TrimPrefix(prefix, root)] = keys
+		}
+	}
+
+	return relative
+}
+
+// common returns the keys of a that also exist in b, keeping the order of a
+func common(a, b []string) []string {
+	inB := map[string]bool{}
+	for _, k := range b {
+		inB[k] = true
+	}
+
+	keys := []string{}
+	for _, k := range a {
+		if inB[k] {
+			keys = append(keys, k)
 		}
 	}
+
+	return keys
 }
 
-// keys stores known missing keys between the two maps
-func (j *jsonAnalyzer) keys(working jsoncfg, master jsoncfg) []string {
+// keyOrder stores the keys of every object of a json document in the order
+// they're written, keyed by the object's path prefix, joined by sep ("" for
+// the top level object). objects within arrays are not stored. keys are
+// passed through transform, if given
+func keyOrder(b []byte, sep string, order map[string][]string, transform func(string) string) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != json.Delim('{') {
+		return errors.New("json config must be an object")
+	}
+
+	if transform == nil {
+		transform = func(k string) string { return k }
+	}
+
+	return orderObject(dec, "", sep, order, transform)
+}
+
+// orderObject stores the key order of the object being decoded, after its
+// opening brace has been read
+func orderObject(dec *json.Decoder, prefix, sep string, order map[string][]string, transform func(string) string) error {
 	keys := []string{}
 
-	for k, _ := range working {
-		keys = append(keys, k)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
 
-		// this key does not exist in the master file, ignore and continue
-		if _, ok := master[k]; !ok {
-			continue
+		key := transform(tok.(string))
+		keys = append(keys, key)
+
+		tok, err = dec.Token()
+		if err != nil {
+			return err
 		}
 
-		// this key contains a nested map
-		if j.isMap(working[k]) {
-			if !j.isMap(master[k]) {
-				j.missing = append(j.missing, k)
+		switch tok {
+		case json.Delim('{'):
+			err = orderObject(dec, joinKey(prefix, key, sep)+sep, sep, order, transform)
+		case json.Delim('['):
+			for dec.More() && err == nil {
+				err = streamValue(dec, "", sep, nil)
+			}
+			if err == nil {
+				_, err = dec.Token()
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	order[prefix] = keys
+
+	// the closing brace
+	_, err := dec.Token()
+	return err
+}
+
+// diff will peform a diff on keys between two maps, storing ones
+// that exist in the master and are missing in the working file. a key holding
+// a nested map on one side only is also considered missing
+func (j *jsonAnalyzer) diff(working jsoncfg, master jsoncfg, prefix string) {
+	sep := j.config.keySeparator()
+
+	for k := range master {
+		path := joinKey(prefix, k, sep)
+
+		if _, ok := working[k]; !ok {
+			// an optional section is noted once rather than as missing
+			if j.isMap(master[k]) && matchPath(j.config.OptionalSections, path, sep) {
+				j.optional = append(j.optional, path)
 				continue
 			}
 
-			// ...and so does the key in the master file. drill down to compare
-			// the next set of maps between working and master
+			j.addMissing(path, master[k])
+			continue
+		}
+
+		workingMap, masterMap := j.isMap(working[k]), j.isMap(master[k])
+
+		if workingMap != masterMap {
+			j.addMissing(path, master[k])
+			continue
+		}
+
+		// both keys contain a nested map. drill down to compare the next
+		// set of maps between working and master
+		if workingMap {
 			j.diff(working[k].(map[string]interface{}),
+				master[k].(map[string]interface{}), path+sep)
+			continue
+		}
+
+		j.diffElements(working[k], master[k], path)
+	}
+}
+
+// diffElements drills down into the objects of two positional arrays at path,
+// comparing the objects at each index both arrays share, so the keys of
+// e.g. servers.0 are compared. elements only one array has are a difference
+// of the arrays' values rather than missing keys
+func (j *jsonAnalyzer) diffElements(working, master interface{}, path string) {
+	w, ok := working.([]interface{})
+	if !ok {
+		return
+	}
+
+	m, ok := master.([]interface{})
+	if !ok || j.arrayMode(path) != ArrayPositional {
+		return
+	}
+
+	sep := j.config.keySeparator()
+
+	for i := 0; i < len(w) && i < len(m); i++ {
+		if j.isMap(w[i]) && j.isMap(m[i]) {
+			j.diff(w[i].(map[string]interface{}), m[i].(map[string]interface{}),
+				path+sep+strconv.Itoa(i)+sep)
+		}
+	}
+}
+
+// compare stores the keys, as dotted paths, that hold a differing value in both
+// maps. values are reported as written in their files, the values of any
+// sensitive keys nested within them redacted
+func (j *jsonAnalyzer) compare(working, master map[string]interface{}, prefix string) {
+	sep := j.config.keySeparator()
+
+	keys := []string{}
+	for k := range master {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		w, ok := working[k]
+		if !ok {
+			continue
+		}
+
+		path := joinKey(prefix, k, sep)
+
+		if m, w := jsonType(master[k]), jsonType(w); j.config.CompareTypes && m != w {
+			j.mismatches = append(j.mismatches, TypeMismatch{Key: path, Master: m, Working: w})
+		}
+
+		workingMap, masterMap := j.isMap(w), j.isMap(master[k])
+		if workingMap && masterMap {
+			j.compare(w.(map[string]interface{}), master[k].(map[string]interface{}), path+sep)
+			continue
+		}
+
+		if workingMap || masterMap {
+			continue
+		}
+
+		if j.equalJsonValues(path, master[k], w) {
+			j.checkTypes(path, master[k], w)
+			continue
+		}
+
+		d := DiffEntry{
+			Key:     path,
+			Master:  rawJson(j.config.redactJson(path, master[k])),
+			Working: rawJson(j.config.redactJson(path, w)),
+		}
+
+		j.different = append(j.different, j.config.redact(d))
+
+		if mode := j.arrayMode(path); mode == ArraySet || mode == ArrayMultiset {
+			j.elementChanges(path, mode, master[k], w)
+		}
+
+		// a value that became a list, or stopped being one, usually needs
+		// the code consuming it to change
+		_, masterArray := master[k].([]interface{})
+		_, workingArray := w.([]interface{})
+
+		switch {
+		case workingArray && !masterArray:
+			j.shapeChanged = append(j.shapeChanged, path+": was scalar, now array")
+		case masterArray && !workingArray:
+			j.shapeChanged = append(j.shapeChanged, path+": was array, now scalar")
+		}
+	}
+}
+
+// checkTypes notes a warning, if Config.StrictTypes is set, when equal values
+// are a string and a number, bool or null, e.g. an env PORT=8080 compared to
+// a json 8080, so the implicit coercion is visible. the values of sensitive
+// keys are redacted
+func (j *jsonAnalyzer) checkTypes(key string, master, working interface{}) {
+	if !j.config.StrictTypes {
+		return
+	}
+
+	m, w := jsonType(master), jsonType(working)
+	if m == w || (m != "string" && w != "string") || m == "array" || w == "array" {
+		return
+	}
+
+	j.typeWarnings = append(j.typeWarnings, fmt.Sprintf("%s: working %s %s compared to master %s %s",
+		key, w, j.config.redactValue(key, jsonText(working)), m, j.config.redactValue(key, jsonText(master))))
+}
+
+// jsonType returns the json type name of a parsed value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "array"
+	}
+
+	return "object"
+}
+
+// jsonText returns a value as json, quoting strings unlike rawJson
+func jsonText(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return rawJson(v)
+	}
+
+	return string(b)
+}
+
+// equalJsonValues determines whether two json values are equal. numbers are
+// compared by value so 1e6 and 1000000 are equal and arrays by the mode
+// configured for their path
+func (j jsonAnalyzer) equalJsonValues(key string, master, working interface{}) bool {
+	if m, ok := master.([]interface{}); ok {
+		master = canonical(m, j.arrayMode(key))
+	}
+
+	if w, ok := working.([]interface{}); ok {
+		working = canonical(w, j.arrayMode(key))
+	}
+
+	m, mIsNumber := master.(json.Number)
+	w, wIsNumber := working.(json.Number)
+
+	if mIsNumber && wIsNumber {
+		mt, mok := numberText(m)
+		wt, wok := numberText(w)
+
+		if mok && wok && mt == wt {
+			return true
+		}
+	}
+
+	return j.equalValues(key, rawJson(master), rawJson(working))
+}
+
+// numberText returns a json number in a canonical form, so that numbers
+// written differently, e.g. 1e6 and 1000000, have the same text. unlike a
+// float64 it is exact for integers well beyond 2^53
+func numberText(n json.Number) (string, bool) {
+	f, _, err := big.ParseFloat(n.String(), 10, numberPrecision, big.ToNearestEven)
+	if err != nil || f.IsInf() {
+		return "", false
+	}
+
+	return f.Text('g', -1), true
+}
+
+// numberPrecision is the bits of mantissa numbers are compared with
+const numberPrecision = 512
+
+// rawJson returns a json value as it's written in its file. strings are
+// returned unquoted and numbers keep their original text
+func rawJson(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+// addMissing stores the dotted path of a missing key and its master value once
+func (j *jsonAnalyzer) addMissing(k string, value interface{}) {
+	if !j.contains(j.missing, k) {
+		j.analyzer.addMissing(k, value)
+	}
+}
+
+// merged returns the working json with any keys missing from it filled in
+// using their master values
+func (j jsonAnalyzer) merged() ([]byte, error) {
+	merged := j.merge(j.jsonWorking, j.jsonMaster)
+
+	bytes, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(bytes, '\n'), nil
+}
+
+// merge returns a copy of the working map with keys that only exist in the
+// master map added to it, drilling down into nested maps that exist in both
+func (j jsonAnalyzer) merge(working, master map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+
+	for k := range working {
+		merged[k] = working[k]
+	}
+
+	for k := range master {
+		if _, ok := working[k]; !ok {
+			merged[k] = master[k]
+			continue
+		}
+
+		if j.isMap(working[k]) && j.isMap(master[k]) {
+			merged[k] = j.merge(working[k].(map[string]interface{}),
 				master[k].(map[string]interface{}))
 		}
 	}
 
-	return keys
+	return merged
 }
 
 // equalKeys will determining whether or not the working file
@@ -102,12 +700,14 @@ func (j jsonAnalyzer) equalKeys() (bool, error) {
 // is identical to the master file
 func (j jsonAnalyzer) equality() (bool, error) {
 
-	bytesA, err := json.Marshal(j.jsonMaster)
+	master := j.fillAccepted(j.jsonMaster, j.jsonWorking)
+
+	bytesA, err := json.Marshal(j.normalizeValues(master, ""))
 	if err != nil {
 		return false, err
 	}
 
-	bytesB, err := json.Marshal(j.jsonWorking)
+	bytesB, err := json.Marshal(j.normalizeValues(j.jsonWorking, ""))
 	if err != nil {
 		return false, err
 	}
@@ -115,6 +715,82 @@ func (j jsonAnalyzer) equality() (bool, error) {
 	return string(bytesA) == string(bytesB), nil
 }
 
+// fillAccepted returns a copy of the master map with each placeholder or
+// ignored value replaced by the working value of the same key, so that the
+// working value is accepted
+func (j jsonAnalyzer) fillAccepted(master, working map[string]interface{}) map[string]interface{} {
+	filled := map[string]interface{}{}
+
+	for k := range master {
+		filled[k] = master[k]
+
+		w, ok := working[k]
+		if !ok {
+			continue
+		}
+
+		value, isString := master[k].(string)
+		wvalue, wIsString := w.(string)
+
+		if isString && (j.placeholder(value) || wIsString && j.accepts(value, wvalue)) {
+			filled[k] = w
+		}
+
+		if j.isMap(master[k]) && j.isMap(w) {
+			filled[k] = j.fillAccepted(master[k].(map[string]interface{}),
+				w.(map[string]interface{}))
+		}
+	}
+
+	return filled
+}
+
+// normalizeValues returns a copy of the given value, at the given dotted
+// path, with known environment tokens substituted in every string value and
+// arrays ordered by the mode configured for their path
+func (j jsonAnalyzer) normalizeValues(v interface{}, path string) interface{} {
+	switch t := v.(type) {
+	case jsoncfg:
+		return j.normalizeValues(map[string]interface{}(t), path)
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		sep := j.config.keySeparator()
+		for k := range t {
+			if path == "" {
+				m[k] = j.normalizeValues(t[k], joinKey("", k, sep))
+			} else {
+				m[k] = j.normalizeValues(t[k], joinKey(path+sep, k, sep))
+			}
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i := range t {
+			s[i] = j.normalizeValues(t[i], path)
+		}
+		return canonical(s, j.arrayMode(path))
+	case string:
+		if j.config.CoerceScalars {
+			return coerce(j.normalize(t))
+		}
+		return j.normalize(t)
+	case json.Number:
+		if j.config.CoerceScalars {
+			return coerce(t.String())
+		}
+		// numbers are compared by value rather than by their text
+		if text, ok := numberText(t); ok {
+			return json.Number(text)
+		}
+	case bool:
+		if j.config.CoerceScalars {
+			return coerce(rawJson(t))
+		}
+	}
+
+	return v
+}
+
 // clearValues will set empty values for each key in a given map
 func (j *jsonAnalyzer) clearValues(m map[string]interface{}) {
 	for k, _ := range m {
@@ -126,6 +802,226 @@ func (j *jsonAnalyzer) clearValues(m map[string]interface{}) {
 	}
 }
 
+// flatten stores every key of a map, including nested keys as paths joined by
+// Config.KeySeparator (e.g. database.replica.host), in keys
+func (j jsonAnalyzer) flatten(m map[string]interface{}, prefix string, keys map[string]bool) {
+	sep := j.config.keySeparator()
+
+	for k := range m {
+		path := joinKey(prefix, k, sep)
+		keys[path] = true
+
+		if j.isMap(m[k]) {
+			j.flatten(m[k].(map[string]interface{}), path+sep, keys)
+		}
+	}
+}
+
+// flattenStream stores every key of a json document, including nested keys as
+// paths joined by sep, in keys. unlike flatten the document is streamed token
+// by token so it is never held in memory as a map, which keeps peak memory
+// low for very large files. it backs the key only paths, ScanKeysOnly,
+// RequireKeys and the validation in ParseJson. ScanJson streams with
+// streamShapes instead, and Scan unmarshals both files in full, as it
+// compares their values
+func flattenStream(r io.Reader, sep string, keys map[string]bool) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != json.Delim('{') {
+		return errors.New("json config must be an object")
+	}
+
+	return streamObject(dec, "", sep, keys)
+}
+
+// streamObject stores the keys of the object being decoded, after its opening
+// brace has been read. keys within arrays are not stored, matching flatten
+func streamObject(dec *json.Decoder, prefix, sep string, keys map[string]bool) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		path := joinKey(prefix, tok.(string), sep)
+		if keys != nil {
+			keys[path] = true
+		}
+
+		if err := streamValue(dec, path+sep, sep, keys); err != nil {
+			return err
+		}
+	}
+
+	// the closing brace
+	_, err := dec.Token()
+	return err
+}
+
+// streamValue consumes the next value being decoded, drilling down into
+// objects and skipping over the contents of arrays
+func streamValue(dec *json.Decoder, prefix, sep string, keys map[string]bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		return streamObject(dec, prefix, sep, keys)
+	case json.Delim('['):
+		for dec.More() {
+			if err := streamValue(dec, prefix, sep, nil); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	}
+
+	return err
+}
+
+// shape is the kind of a value of a streamed json document, and the path of
+// the object or array holding it. element is whether it's held by an array
+type shape struct {
+	object, array bool
+	element       bool
+	parent        string
+}
+
+// streamShapes stores the shape of every value of a json document by its
+// path, including the elements of arrays by their index, e.g. servers.0.
+// like flattenStream the document is streamed rather than held as a map
+func streamShapes(b []byte, sep string) (map[string]shape, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok != json.Delim('{') {
+		return nil, errors.New("json config must be an object")
+	}
+
+	shapes := map[string]shape{}
+	if err := streamMembers(dec, "", sep, shapes); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+
+	return shapes, nil
+}
+
+// streamMembers stores the shapes of the members of the object at path, after
+// its opening brace has been read
+func streamMembers(dec *json.Decoder, path, sep string, shapes map[string]shape) error {
+	prefix := ""
+	if path != "" {
+		prefix = path + sep
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if err := streamShape(dec, joinKey(prefix, tok.(string), sep), shape{parent: path}, sep, shapes); err != nil {
+			return err
+		}
+	}
+
+	// the closing brace
+	_, err := dec.Token()
+	return err
+}
+
+// streamShape stores the shape of the next value being decoded at path, and
+// those of the values within it
+func streamShape(dec *json.Decoder, path string, s shape, sep string, shapes map[string]shape) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		s.object = true
+		shapes[path] = s
+		return streamMembers(dec, path, sep, shapes)
+	case json.Delim('['):
+		s.array = true
+		shapes[path] = s
+		for i := 0; dec.More(); i++ {
+			if err := streamShape(dec, path+sep+strconv.Itoa(i), shape{parent: path, element: true}, sep, shapes); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	default:
+		shapes[path] = s
+	}
+
+	return err
+}
+
+// streamedMissing returns the sorted paths of the master's keys missing from
+// the working file, found from their shapes as diff finds them, with every
+// array compared by position
+func streamedMissing(working, master map[string]shape) []string {
+	// whether diff drills into the values at path of both files
+	within := map[string]bool{"": true}
+
+	var into func(path string) bool
+	into = func(path string) bool {
+		if ok, seen := within[path]; seen {
+			return ok
+		}
+
+		m := master[path]
+		w, ok := working[path]
+
+		switch {
+		case !ok || !m.object || !w.object:
+			ok = false
+		case !m.element:
+			ok = into(m.parent)
+		default:
+			// the objects of positional arrays, not those of nested arrays
+			a, wa := master[m.parent], working[m.parent]
+			ok = !a.element && a.array && wa.array && into(a.parent)
+		}
+
+		within[path] = ok
+		return ok
+	}
+
+	// nil when nothing is missing, as diff leaves it
+	var missing []string
+	for path, m := range master {
+		if m.element || !into(m.parent) {
+			continue
+		}
+
+		if w, ok := working[path]; !ok || w.object != m.object {
+			missing = append(missing, path)
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing
+}
+
 // isMap determins if the interface passed in is a go map or not
 func (j jsonAnalyzer) isMap(m interface{}) bool {
 	_, ok := m.(map[string]interface{})
@@ -1,6 +1,14 @@
 package cfg
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -57,3 +65,704 @@ func TestJsonEqual(t *testing.T) {
 		t.Fatal("values should not be equal")
 	}
 }
+
+func TestJsonExtra(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/b.json",
+		MasterPath:  "test/a.json",
+	}
+
+	analyzer, err := newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if len(analyzer.missing) != 0 {
+		t.Fatalf("expected=%d actual=%d", 0, len(analyzer.missing))
+	}
+
+	if len(analyzer.extra) != 2 {
+		t.Fatalf("expected=%d actual=%d", 2, len(analyzer.extra))
+	}
+}
+
+func TestJsonForbiddenKeys(t *testing.T) {
+	c := Config{
+		WorkingPath:   "test/b.json",
+		MasterPath:    "test/a.json",
+		ForbiddenKeys: []string{"3.*"},
+	}
+
+	analyzer, err := newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	expected := []string{"3.4", "3.5"}
+
+	if len(analyzer.forbidden) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.forbidden)
+	}
+
+	for i := range expected {
+		if analyzer.forbidden[i] != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], analyzer.forbidden[i])
+		}
+	}
+}
+
+func TestJsonPlaceholders(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/e.json",
+		MasterPath:  "test/f.json",
+	}
+
+	analyzer, err := newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if equal, _ := analyzer.equality(); equal {
+		t.Fatal("values should not be equal")
+	}
+
+	c.Placeholders = []string{"<*>"}
+
+	analyzer, err = newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if equal, _ := analyzer.equality(); !equal {
+		t.Fatal("placeholder values should be treated as equal")
+	}
+}
+
+func TestJsonNestedMissing(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/nested/working.json",
+		MasterPath:  "test/nested/master.json",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(result.Missing)
+
+	expected := []string{"database.replica.host", "servers.0.port", "servers.1.tls.cert"}
+	if !reflect.DeepEqual(result.Missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, result.Missing)
+	}
+
+	// the third server is a difference of the array rather than extra keys
+	if len(result.Extra) != 0 {
+		t.Fatalf("expected=[] actual=%v", result.Extra)
+	}
+
+	// arrays compared as sets have no positions to compare
+	c.ArrayModes = map[string]ArrayMode{"servers": ArraySet}
+
+	if result, err = Scan(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"database.replica.host"}; !reflect.DeepEqual(result.Missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, result.Missing)
+	}
+}
+
+func TestJsonCompareTypes(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/types/working.json",
+		MasterPath:  "test/types/master.json",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.TypeMismatches) != 0 {
+		t.Fatalf("expected=[] actual=%+v", result.TypeMismatches)
+	}
+
+	c.CompareTypes = true
+
+	if result, err = Scan(c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []TypeMismatch{
+		{Key: "cache", Master: "object", Working: "string"},
+		{Key: "port", Master: "number", Working: "string"},
+		{Key: "timeout", Master: "null", Working: "number"},
+	}
+
+	if !reflect.DeepEqual(result.TypeMismatches, expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.TypeMismatches)
+	}
+
+	if !strings.Contains(result.Diff(), "type mismatches (3):\n  cache: object -> string\n") {
+		t.Fatalf("expected the mismatches in the diff actual=%s", result.Diff())
+	}
+
+	// every env value is a string
+	c.WorkingPath, c.EnvSeparator = "test/m.env", "_"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an error for an env working file")
+	}
+}
+
+func TestJsonEnvSeparator(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/m.env",
+		MasterPath:   "test/g.json",
+		EnvSeparator: "_",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "db.user" {
+		t.Fatalf("expected=[db.user] actual=%+v", result.Missing)
+	}
+
+	if len(result.Extra) != 0 {
+		t.Fatalf("expected=[] actual=%+v", result.Extra)
+	}
+}
+
+func TestFlattenStream(t *testing.T) {
+	doc := `{"a": 1, "b": {"c": [1, {"x": 2}], "d": {"e": null}}, "f": "g"}`
+
+	streamed := map[string]bool{}
+	if err := flattenStream(strings.NewReader(doc), defaultKeySeparator, streamed); err != nil {
+		t.Fatal(err)
+	}
+
+	m := jsoncfg{}
+	if err := json.Unmarshal([]byte(doc), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	flattened := map[string]bool{}
+	jsonAnalyzer{}.flatten(m, "", flattened)
+
+	if len(streamed) != len(flattened) {
+		t.Fatalf("expected=%+v actual=%+v", flattened, streamed)
+	}
+
+	for k := range flattened {
+		if !streamed[k] {
+			t.Fatalf("expected key %s to be streamed", k)
+		}
+	}
+
+	if err := flattenStream(strings.NewReader(`[1, 2]`), defaultKeySeparator, map[string]bool{}); err == nil {
+		t.Fatal("expected an error for a non object document")
+	}
+}
+
+// largeJson builds a nested json document of roughly 10MB
+func largeJson() []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("{")
+	for i := 0; i < 20000; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `"service%d": {"host": "host%d.example.com", "port": %d, "tags": ["a", "b", "c"], `, i, i, i)
+		fmt.Fprintf(&buf, `"pool": {"min": 1, "max": 10, "idle": "30s"}, "description": "%s"}`, strings.Repeat("x", 400))
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes()
+}
+
+func BenchmarkFlattenUnmarshal(b *testing.B) {
+	doc := largeJson()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		m := jsoncfg{}
+		if err := json.Unmarshal(doc, &m); err != nil {
+			b.Fatal(err)
+		}
+		jsonAnalyzer{}.flatten(m, "", map[string]bool{})
+	}
+}
+
+func BenchmarkFlattenStream(b *testing.B) {
+	doc := largeJson()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := flattenStream(bytes.NewReader(doc), defaultKeySeparator, map[string]bool{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// largeJsonPair writes a working and master json file of largeJson
+func largeJsonPair(b *testing.B) Config {
+	dir := b.TempDir()
+	c := Config{WorkingPath: filepath.Join(dir, "config.json"), MasterPath: filepath.Join(dir, "config.example.json")}
+
+	for _, path := range []string{c.WorkingPath, c.MasterPath} {
+		if err := ioutil.WriteFile(path, largeJson(), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return c
+}
+
+func BenchmarkScanJson(b *testing.B) {
+	c := largeJsonPair(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ScanJson(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanJsonResult(b *testing.B) {
+	c := largeJsonPair(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ScanJsonResult(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanKeysOnlyJson(b *testing.B) {
+	c := largeJsonPair(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ScanKeysOnly(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestJsonDifferentNumbers(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/s.json",
+		MasterPath:  "test/t.json",
+	}
+
+	analyzer, err := newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	expected := []DiffEntry{
+		{Key: "app.port", Master: "8081", Working: "8080"},
+		// integers beyond 2^53 differ though they are the same float64
+		{Key: "id", Master: "9007199254740992", Working: "9007199254740993"},
+		{Key: "limit", Master: "1000000", Working: "2000000"},
+	}
+
+	if fmt.Sprintf("%+v", analyzer.different) != fmt.Sprintf("%+v", expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, analyzer.different)
+	}
+}
+
+func TestJsonOrdered(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/y.json",
+		MasterPath:  "test/g.json",
+		JSONOrdered: true,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"db", "app", "db.port", "db.user"}
+
+	if len(result.OrderChanged) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.OrderChanged)
+	}
+
+	for i := range expected {
+		if result.OrderChanged[i] != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], result.OrderChanged[i])
+		}
+	}
+
+	c.JSONOrdered = false
+
+	result, err = Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.OrderChanged) != 0 {
+		t.Fatalf("expected no order changes actual=%+v", result.OrderChanged)
+	}
+}
+
+func TestJsonKeyTransform(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/transform/working.json",
+		MasterPath:  "test/transform/master.json",
+		KeyTransform: func(k string) string {
+			return strings.ReplaceAll(k, "-", "_")
+		},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 0 || len(result.Extra) != 0 {
+		t.Fatalf("expected no missing or extra keys actual=%+v %+v", result.Missing, result.Extra)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Key != "db.max_conns" {
+		t.Fatalf("expected=[db.max_conns] actual=%+v", result.Different)
+	}
+}
+
+func TestJsonEqualTrailingNewline(t *testing.T) {
+	dir := t.TempDir()
+
+	doc := `{"fruit": "mango", "animal": {"name": "koala"}}`
+	working, master := filepath.Join(dir, "working.json"), filepath.Join(dir, "master.json")
+
+	if err := ioutil.WriteFile(working, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(master, []byte(doc+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer, err := newJsonAnalyzer(Config{WorkingPath: working, MasterPath: master})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if equal, _ := analyzer.equality(); !equal || len(analyzer.different) != 0 {
+		t.Fatalf("expected files differing only by a final newline to be equal actual=%+v", analyzer.different)
+	}
+}
+
+func TestJsonRootPath(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/root/working.json",
+		MasterPath:  "test/root/master.json",
+		RootPath:    "logging",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// db and cache are outside of the subtree
+	if len(result.Missing) != 1 || result.Missing[0] != "format" || len(result.Extra) != 0 {
+		t.Fatalf("expected=[format] actual=%+v extra=%+v", result.Missing, result.Extra)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Key != "level" {
+		t.Fatalf("expected=[level] actual=%+v", result.Different)
+	}
+
+	c.RootPath = "db"
+
+	result, err = Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "host" {
+		t.Fatalf("expected the whole subtree to be missing actual=%+v", result.Missing)
+	}
+
+	c.RootPath = "nope"
+	if _, err := Scan(c); err == nil {
+		t.Fatal("expected an error for a root path in neither file")
+	}
+}
+
+func TestJsonCoerceScalars(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/coerce/working.env",
+		MasterPath:   "test/coerce/master.json",
+		EnvSeparator: "_",
+	}
+
+	analyzer, err := newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if equal, _ := analyzer.equality(); equal || len(analyzer.different) == 0 {
+		t.Fatal("typed and string values should differ without coercion")
+	}
+
+	c.CoerceScalars = true
+
+	analyzer, err = newJsonAnalyzer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer.scan()
+
+	if equal, _ := analyzer.equality(); !equal || len(analyzer.different) != 0 {
+		t.Fatalf("coerced values should be equal actual=%+v", analyzer.different)
+	}
+}
+
+func TestJsonKeySeparator(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/separator/working.json",
+		MasterPath:  "test/separator/master.json",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the "a.b" key does not collide with the nested a.b path
+	keys := map[string]bool{}
+	for _, d := range result.Different {
+		keys[d.Key] = true
+	}
+
+	if len(keys) != 2 || !keys["[a.b]"] || !keys["a.b"] {
+		t.Fatalf("expected=[[a.b] a.b] actual=%+v", result.Different)
+	}
+
+	c.KeySeparator = "/"
+
+	result, err = Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys = map[string]bool{}
+	for _, d := range result.Different {
+		keys[d.Key] = true
+	}
+
+	if len(keys) != 2 || !keys["a.b"] || !keys["a/b"] {
+		t.Fatalf("expected=[a.b a/b] actual=%+v", result.Different)
+	}
+}
+
+func TestJsonResolveRefs(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/refs/working.json",
+		MasterPath:  "test/refs/master.json",
+		ResolveRefs: true,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// db is inlined from shared/db.json and compared key by key
+	expected := []string{"name", "replica.host"}
+
+	if len(result.Different) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.Different)
+	}
+
+	for i := range expected {
+		if result.Different[i].Key != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], result.Different[i].Key)
+		}
+	}
+
+	if result.Different[0].Working != "localhost" {
+		t.Fatalf("expected a same file reference to be inlined actual=%+v", result.Different[0])
+	}
+
+	c.MasterPath = "test/refs/cycle.json"
+	if _, err := Scan(c); err == nil || !strings.Contains(err.Error(), "reference cycle") {
+		t.Fatalf("expected a reference cycle error actual=%v", err)
+	}
+}
+
+func TestYamlResolveIncludes(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/refs/yaml/working.yaml",
+		MasterPath:  "test/refs/yaml/master.yaml",
+		ResolveRefs: true,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// db is included from shared/db.yaml, which includes its replica in turn
+	expected := []DiffEntry{{Key: "db.port", Master: "5432", Working: "5433"}}
+
+	if !reflect.DeepEqual(expected, result.Different) || len(result.Missing) != 0 {
+		t.Fatalf("expected=%+v actual=%+v", expected, result)
+	}
+
+	c.MasterPath = "test/refs/yaml/cycle.yaml"
+	if _, err := Scan(c); err == nil || !strings.Contains(err.Error(), "reference cycle") {
+		t.Fatalf("expected a reference cycle error actual=%v", err)
+	}
+}
+
+func TestJsonMissingRequired(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/required/working.json",
+		MasterPath:  "test/required/master.json",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.MissingWithDefault) != 1 || result.MissingWithDefault[0] != "port" {
+		t.Fatalf("expected=[port] actual=%+v", result.MissingWithDefault)
+	}
+
+	required := map[string]bool{}
+	for _, key := range result.MissingRequired {
+		required[key] = true
+	}
+
+	if len(required) != 2 || !required["secret"] || !required["token"] {
+		t.Fatalf("expected=[secret token] actual=%+v", result.MissingRequired)
+	}
+}
+
+func TestJsonStrictTypes(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/strict/working.env",
+		MasterPath:   "test/strict/master.json",
+		EnvSeparator: "_",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Different) != 0 || len(result.TypeWarnings) != 0 {
+		t.Fatalf("expected no differences or warnings actual=%+v", result)
+	}
+
+	c.StrictTypes = true
+
+	result, err = Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`db.port: working string "5432" compared to master number 5432`,
+		`db.ssl: working string "true" compared to master bool true`,
+	}
+
+	if len(result.TypeWarnings) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.TypeWarnings)
+	}
+
+	for i := range expected {
+		if result.TypeWarnings[i] != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], result.TypeWarnings[i])
+		}
+	}
+}
+
+func TestJsonOptionalSections(t *testing.T) {
+	c := Config{
+		WorkingPath:      "test/optional/working.json",
+		MasterPath:       "test/optional/master.json",
+		OptionalSections: []string{"metrics", "tracing"},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.OptionalSections) != 1 || result.OptionalSections[0] != "metrics" {
+		t.Fatalf("expected=[metrics] actual=%+v", result.OptionalSections)
+	}
+
+	// tracing is partially configured so its keys are still compared
+	if len(result.Missing) != 1 || result.Missing[0] != "tracing.endpoint" {
+		t.Fatalf("expected=[tracing.endpoint] actual=%+v", result.Missing)
+	}
+
+	c.OptionalSections = nil
+
+	result, err = Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 2 || len(result.OptionalSections) != 0 {
+		t.Fatalf("expected metrics and endpoint to be missing actual=%+v", result)
+	}
+}
+
+func TestJsonShapeChanged(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/v.json",
+		MasterPath:  "test/u.json",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"endpoints: was scalar, now array", "ports: was array, now scalar"}
+
+	if len(result.ShapeChanged) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.ShapeChanged)
+	}
+
+	for i := range expected {
+		if result.ShapeChanged[i] != expected[i] {
+			t.Fatalf("expected=%s actual=%s", expected[i], result.ShapeChanged[i])
+		}
+	}
+
+	if len(result.Different) != 2 {
+		t.Fatalf("expected both keys to also differ actual=%+v", result.Different)
+	}
+}
@@ -0,0 +1,102 @@
+package cfg
+
+import (
+	"bytes"
+	"sort"
+)
+
+// ScanKeysOnly returns the keys that exist in the master file and are
+// missing in the working file, comparing keys alone. values aren't parsed or
+// compared where possible, making it faster than Scan for presence audits of
+// large files. nested json keys are returned as dotted paths, sorted, and env
+// keys in the order of the master file
+func ScanKeysOnly(c Config) ([]string, error) {
+	a, err := newAnalyzer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.format().nested() {
+		working, err := keysOf(a.working, FormatJson, c.keySeparator())
+		if err != nil {
+			return nil, err
+		}
+
+		master, err := keysOf(a.master, FormatJson, c.keySeparator())
+		if err != nil {
+			return nil, err
+		}
+
+		missing := []string{}
+		for key := range master {
+			if !working[key] {
+				missing = append(missing, key)
+			}
+		}
+
+		sort.Strings(missing)
+
+		return missing, nil
+	}
+
+	working := set(envKeys(a.working))
+
+	missing := []string{}
+	for _, key := range envKeys(a.master) {
+		if !working[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing, nil
+}
+
+// envKeys returns the keys of env file bytes in order, without parsing their
+// values beyond skipping the lines of multi-line quoted values. malformed
+// lines are skipped
+func envKeys(b []byte) []string {
+	lines := bytes.Split(b, []byte("\n"))
+	keys := []string{}
+
+	for i := 0; i < len(lines); i++ {
+		line := bytes.TrimSuffix(lines[i], []byte("\r"))
+
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		eq := bytes.IndexByte(line, '=')
+		if eq <= 0 {
+			continue
+		}
+
+		keys = append(keys, string(line[:eq]))
+
+		// skip to the line closing a multi-line value, as parse does
+		if value := line[eq+1:]; len(value) > 0 && value[0] == '"' && quoteEnd(value[1:]) < 0 {
+			for end := i + 1; end < len(lines); end++ {
+				if quoteEnd(lines[end]) >= 0 {
+					i = end
+					break
+				}
+			}
+		}
+	}
+
+	return keys
+}
+
+// quoteEnd returns the index of the first double quote in b that isn't
+// escaped, or -1
+func quoteEnd(b []byte) int {
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
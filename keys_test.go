@@ -0,0 +1,89 @@
+package cfg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanKeysOnly(t *testing.T) {
+	tests := []struct {
+		working, master string
+		expected        []string
+	}{
+		{"test/a.env", "test/b.env", []string{"FOOD", "LANG", "DRINK"}},
+		{"test/multiline/working.env", "test/multiline/master.env", []string{}},
+		{"test/required/working.json", "test/required/master.json", []string{"port", "secret", "token"}},
+	}
+
+	for _, tt := range tests {
+		missing, err := ScanKeysOnly(Config{WorkingPath: tt.working, MasterPath: tt.master})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Join(missing, ",") != strings.Join(tt.expected, ",") {
+			t.Fatalf("expected=%+v actual=%+v", tt.expected, missing)
+		}
+	}
+}
+
+func TestEnvKeys(t *testing.T) {
+	b := []byte("# comment\nA=1\nB=\"multi\nC=line\"\nmalformed\nD=\"open\nE=2\n")
+
+	// the lines after a quote that is never closed are parsed as usual
+	expected := "A,B,D,E"
+	if actual := strings.Join(envKeys(b), ","); actual != expected {
+		t.Fatalf("expected=%s actual=%s", expected, actual)
+	}
+}
+
+// largeEnvPair writes a working and master env file of many keys
+func largeEnvPair(b *testing.B) Config {
+	var working, master strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&master, "KEY_%d=%s\n", i, strings.Repeat("x", 100))
+		if i%10 != 0 {
+			fmt.Fprintf(&working, "KEY_%d=%s\n", i, strings.Repeat("y", 100))
+		}
+	}
+
+	dir := b.TempDir()
+	c := Config{WorkingPath: filepath.Join(dir, ".env"), MasterPath: filepath.Join(dir, ".env.example")}
+
+	if err := ioutil.WriteFile(c.WorkingPath, []byte(working.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(c.MasterPath, []byte(master.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	return c
+}
+
+func BenchmarkScan(b *testing.B) {
+	c := largeEnvPair(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Scan(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanKeysOnly(b *testing.B) {
+	c := largeEnvPair(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ScanKeysOnly(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// loadMaster reads the master file, or merges each of Config.MasterPaths
+// into a single master when set
+func (a *analyzer) loadMaster(masterPath string) error {
+	if len(a.config.MasterPaths) == 0 {
+		return a.readMaster(masterPath)
+	}
+
+	return a.readMasters(a.config.MasterPaths)
+}
+
+// readMasters reads and merges several master files into one. later files
+// override the keys of earlier ones, as layered configs resolve. the file
+// each key was last defined in is kept in sources
+func (a *analyzer) readMasters(paths []string) error {
+	a.sources = map[string]string{}
+
+	if a.config.format().nested() {
+		merged := map[string]interface{}{}
+
+		for _, path := range paths {
+			if err := a.readMaster(path); err != nil {
+				return err
+			}
+
+			m := jsoncfg{}
+			if err := unmarshalFile(a.config, a.master, path, &m); err != nil {
+				return fmt.Errorf("could not parse %s. %s", path, err)
+			}
+
+			overlay(merged, m, "", a.config.keySeparator(), path, a.sources)
+		}
+
+		var err error
+		a.master, err = json.Marshal(merged)
+		return err
+	}
+
+	keys, values := []string{}, map[string]string{}
+
+	for _, path := range paths {
+		if err := a.readMaster(path); err != nil {
+			return err
+		}
+
+		env, err := envAnalyzer{}.unmarshal(strings.Split(string(a.master), "\n"))
+		if err != nil {
+			return fmt.Errorf("could not parse %s. %s", path, err)
+		}
+
+		for _, e := range env {
+			if _, ok := values[e.Key]; !ok {
+				keys = append(keys, e.Key)
+			}
+			values[e.Key] = e.Value
+			a.sources[e.Key] = path
+		}
+	}
+
+	merged := ""
+	for _, key := range keys {
+		merged += fmt.Sprintf("%s=%s\n", key, values[key])
+	}
+
+	a.master = []byte(merged)
+
+	return nil
+}
+
+// overlay copies the keys of src over those of dst, drilling down into nested
+// maps that exist in both, and records path as the source of every key copied
+// by its path joined by sep
+func overlay(dst, src map[string]interface{}, prefix, sep, path string, sources map[string]string) {
+	for k, v := range src {
+		key := joinKey(prefix, k, sep)
+		sources[key] = path
+
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+
+		if srcIsMap && dstIsMap {
+			overlay(dstMap, srcMap, key+sep, sep, path, sources)
+			continue
+		}
+
+		if srcIsMap {
+			// record the sources of the nested keys being copied
+			overlay(map[string]interface{}{}, srcMap, key+sep, sep, path, sources)
+		}
+
+		dst[k] = v
+	}
+}
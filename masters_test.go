@@ -0,0 +1,57 @@
+package cfg
+
+import "testing"
+
+func TestScanMasterPathsEnv(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/masters/working.env",
+		MasterPaths: []string{"test/masters/base.env", "test/masters/prod.env"},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"SPORT": "test/masters/base.env",
+		"CITY":  "test/masters/prod.env",
+	}
+
+	if len(result.Missing) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.Missing)
+	}
+
+	for _, key := range result.Missing {
+		if result.MissingSources[key] != expected[key] {
+			t.Fatalf("key=%s expected=%s actual=%s", key, expected[key], result.MissingSources[key])
+		}
+	}
+
+	// prod.env overrides the value of ANIMAL in base.env
+	if len(result.Different) != 1 || result.Different[0].Master != "Wombat" ||
+		result.Different[0].SourceFile != "test/masters/prod.env" {
+		t.Fatalf("expected ANIMAL to differ from prod.env actual=%+v", result.Different)
+	}
+}
+
+func TestScanMasterPathsJson(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/masters/working.json",
+		MasterPaths: []string{"test/masters/base.json", "test/masters/prod.json"},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || result.MissingSources["region"] != "test/masters/prod.json" {
+		t.Fatalf("expected region to be missing from prod.json actual=%+v %+v", result.Missing, result.MissingSources)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Key != "db.host" ||
+		result.Different[0].SourceFile != "test/masters/prod.json" {
+		t.Fatalf("expected db.host to differ from prod.json actual=%+v", result.Different)
+	}
+}
@@ -0,0 +1,79 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat is the format the Print functions write their report in
+type OutputFormat string
+
+const (
+	// OutputText is the free-form report of (!) and (i) lines
+	OutputText OutputFormat = "text"
+
+	// OutputJson is the Result as indented json
+	OutputJson OutputFormat = "json"
+
+	// OutputYaml is the Result as yaml, with the keys of its json
+	OutputYaml OutputFormat = "yaml"
+)
+
+// WriteResult writes a Result to w in the given format, for tools parsing
+// the report. text is the categorized report of Result.Diff
+func WriteResult(w io.Writer, r *Result, format OutputFormat) error {
+	var b []byte
+
+	switch format {
+	case "", OutputText:
+		b = []byte(r.Diff())
+	case OutputJson:
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		b = append(out, '\n')
+	case OutputYaml:
+		out, err := resultYaml(r)
+		if err != nil {
+			return err
+		}
+		b = out
+	default:
+		return fmt.Errorf("unsupported output format %s", format)
+	}
+
+	_, err := w.Write(b)
+	return err
+}
+
+// resultYaml marshals a Result as yaml. it's marshalled as json first, which
+// yaml can read, so that both formats share the same keys in the same order
+func resultYaml(r *Result) ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	yamlBlock(&doc)
+
+	return yaml.Marshal(&doc)
+}
+
+// yamlBlock clears the json styles of a node and each node within it, so
+// they're written as block yaml, quoting only where needed
+func yamlBlock(n *yaml.Node) {
+	n.Style = 0
+
+	for _, child := range n.Content {
+		yamlBlock(child)
+	}
+}
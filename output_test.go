@@ -0,0 +1,109 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteResult(t *testing.T) {
+	r := &Result{
+		WorkingPath: "a.env",
+		MasterPath:  "b.env",
+		Missing:     []string{"FOOD"},
+		Extra:       []string{},
+		Different:   []DiffEntry{{Key: "PORT", Master: "80", Working: "8080"}},
+	}
+
+	tests := []struct {
+		format   OutputFormat
+		expected string
+	}{
+		{"", r.Diff()},
+		{OutputText, r.Diff()},
+		{OutputYaml, `workingPath: a.env
+masterPath: b.env
+missing:
+    - FOOD
+extra: []
+different:
+    - key: PORT
+      master: "80"
+      working: "8080"
+scannedAt: "0001-01-01T00:00:00Z"
+duration: 0
+`},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteResult(&buf, r, tt.format); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != tt.expected {
+			t.Fatalf("format=%s expected=%s actual=%s", tt.format, tt.expected, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResult(&buf, r, OutputJson); err != nil {
+		t.Fatal(err)
+	}
+
+	actual := Result{}
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(*r, actual) {
+		t.Fatalf("expected=%+v actual=%+v", *r, actual)
+	}
+
+	if err := WriteResult(&buf, r, "xml"); err == nil {
+		t.Fatal("expected an unsupported output format error")
+	}
+}
+
+func TestPrintOutputFormat(t *testing.T) {
+	tests := []struct {
+		print     func(Config) error
+		c         Config
+		unmarshal func([]byte, interface{}) error
+	}{
+		{PrintEnv, Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", OutputFormat: OutputJson}, json.Unmarshal},
+		{PrintEnv, Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", OutputFormat: OutputYaml}, yaml.Unmarshal},
+		{PrintJson, Config{WorkingPath: "test/a.json", MasterPath: "test/b.json", OutputFormat: OutputYaml}, yaml.Unmarshal},
+	}
+
+	for _, tt := range tests {
+		var err error
+		out := captureStdout(t, func() { err = tt.print(tt.c) })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(out, "(!)") {
+			t.Fatalf("format=%s expected no text report actual=%s", tt.c.OutputFormat, out)
+		}
+
+		actual := struct {
+			Missing []string `json:"missing" yaml:"missing"`
+		}{}
+		if err := tt.unmarshal([]byte(out), &actual); err != nil {
+			t.Fatalf("format=%s %s", tt.c.OutputFormat, err)
+		}
+
+		if len(actual.Missing) == 0 {
+			t.Fatalf("format=%s expected missing keys actual=%s", tt.c.OutputFormat, out)
+		}
+	}
+
+	if err := PrintEnv(Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", OutputFormat: "xml"}); err == nil {
+		t.Fatal("expected an unsupported output format error")
+	}
+}
@@ -0,0 +1,85 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+)
+
+// ParseEnv parses the bytes of an env file into its key value pairs. it is
+// safe to call on arbitrary, untrusted input, returning an error for a
+// malformed file rather than panicking
+func ParseEnv(b []byte) (values map[string]string, err error) {
+	defer recoverParse(&err)
+	return parseEnv(b)
+}
+
+// parseEnv parses the bytes of an env file into its key value pairs
+func parseEnv(b []byte) (map[string]string, error) {
+	env, err := envAnalyzer{}.unmarshal(strings.Split(string(b), "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, e := range env {
+		values[e.Key] = e.Value
+	}
+
+	return values, nil
+}
+
+// ParseJson parses the bytes of a json file into a map. numbers are kept as
+// json.Number so their original text is preserved. it is safe to call on
+// arbitrary, untrusted input, returning an error for a malformed file rather
+// than panicking
+func ParseJson(b []byte) (m map[string]interface{}, err error) {
+	defer recoverParse(&err)
+	return parseJson(b)
+}
+
+// parseJson parses the bytes of a json file into a map
+func parseJson(b []byte) (map[string]interface{}, error) {
+	// streaming the keys first validates the document is an object
+	if err := flattenStream(bytes.NewReader(b), defaultKeySeparator, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	if err := unmarshalJson(b, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ParseYaml parses the bytes of a yaml file into a map, as json would be
+// parsed once its aliases and merge keys are resolved. it is safe to call on
+// arbitrary, untrusted input, returning an error for a malformed file rather
+// than panicking
+func ParseYaml(b []byte) (m map[string]interface{}, err error) {
+	defer recoverParse(&err)
+	return parseYaml(b)
+}
+
+// parseYaml parses the bytes of a yaml file into a map
+func parseYaml(b []byte) (map[string]interface{}, error) {
+	j, err := yamlToJson(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJson(j)
+}
+
+// recoverParse turns a panic while parsing into an error. the parsers
+// shouldn't panic on any input, so this is a last resort that logs the panic
+// as the bug it is
+func recoverParse(err *error) {
+	if r := recover(); r != nil {
+		slog.Default().Error("recovered from a panic while parsing", "panic", r, "stack", string(debug.Stack()))
+		*err = fmt.Errorf("could not parse config. %v", r)
+	}
+}
@@ -0,0 +1,99 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	values, err := ParseEnv([]byte("FRUIT=Mango\nTOKEN=abc==\n# comment\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values["FRUIT"] != "Mango" || values["TOKEN"] != "abc==" || len(values) != 2 {
+		t.Fatalf("unexpected values %+v", values)
+	}
+
+	if _, err := ParseEnv([]byte("JUST_A_KEY")); err == nil {
+		t.Fatal("expected an error for a malformed line")
+	}
+}
+
+func TestParseYaml(t *testing.T) {
+	m, err := ParseYaml([]byte("base: &base\n  port: 5432\ndb:\n  <<: *base\n  host: localhost\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rawJson(m["db"]) != `{"host":"localhost","port":5432}` {
+		t.Fatalf("unexpected map %+v", m)
+	}
+
+	for _, doc := range []string{"- a\n- b", "a: [", "a: 1\na: 2", "<<: 1", "a: &a\n  <<: *a\n  b: 1"} {
+		if _, err := ParseYaml([]byte(doc)); err == nil {
+			t.Fatalf("expected an error for %s", doc)
+		}
+	}
+}
+
+func TestParseJson(t *testing.T) {
+	m, err := ParseJson([]byte(`{"db": {"port": 5432}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rawJson(m["db"]) != `{"port":5432}` {
+		t.Fatalf("unexpected map %+v", m)
+	}
+
+	for _, doc := range []string{``, `[]`, `{"a": }`, `{"a": 1} {}`, `"str"`} {
+		if _, err := ParseJson([]byte(doc)); err == nil {
+			t.Fatalf("expected an error for %s", doc)
+		}
+	}
+}
+
+func FuzzParseEnv(f *testing.F) {
+	for _, path := range []string{"test/a.env", "test/i.env", "test/w.env"} {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	// the unexported parsers don't recover, so any panic fails the fuzzer
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseEnv(b)
+	})
+}
+
+func FuzzParseJson(f *testing.F) {
+	for _, path := range []string{"test/a.json", "test/g.json", "test/s.json"} {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseJson(b)
+		keyOrder(b, defaultKeySeparator, map[string][]string{}, nil)
+	})
+}
+
+func FuzzParseYaml(f *testing.F) {
+	for _, path := range []string{"test/yaml/master.yaml", "test/yaml/anchors/master.yaml", "test/yaml/anchors/working.yaml"} {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseYaml(b)
+	})
+}
@@ -0,0 +1,99 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Value is a value of a parsed config: a string, bool, number, nil, a slice
+// of values or a nested map[string]Value
+type Value = interface{}
+
+// Parser parses the raw bytes of a config file into its keys and values, so
+// that a custom format is scanned and reported like any built in one
+type Parser interface {
+	Parse(b []byte) (map[string]Value, error)
+}
+
+// ParserFunc adapts a function to a Parser
+type ParserFunc func(b []byte) (map[string]Value, error)
+
+// Parse calls f(b)
+func (f ParserFunc) Parse(b []byte) (map[string]Value, error) {
+	return f(b)
+}
+
+// parsers holds the formats added with RegisterFormat
+var parsers = struct {
+	mu         sync.RWMutex
+	formats    map[Format]Parser
+	extensions map[string]Format
+}{
+	formats:    map[Format]Parser{},
+	extensions: map[string]Format{},
+}
+
+// RegisterFormat adds a custom format, parsed by p and compared as nested
+// keys like json. files are detected as the format by any of the extensions,
+// e.g. ".conf", that aren't those of a built in format. it panics if the name
+// is already a format or p is nil, so it's typically called from an init func
+func RegisterFormat(name string, p Parser, extensions ...string) {
+	f := Format(name)
+
+	if p == nil {
+		panic("cfg: RegisterFormat parser is nil")
+	}
+
+	if f == "" || f.builtin() {
+		panic(fmt.Sprintf("cfg: RegisterFormat %s is a built in format", name))
+	}
+
+	parsers.mu.Lock()
+	defer parsers.mu.Unlock()
+
+	if _, ok := parsers.formats[f]; ok {
+		panic(fmt.Sprintf("cfg: RegisterFormat called twice for %s", name))
+	}
+
+	parsers.formats[f] = p
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		parsers.extensions[ext] = f
+	}
+}
+
+// parser returns the Parser of a registered format, or nil if it isn't one
+func (f Format) parser() Parser {
+	parsers.mu.RLock()
+	defer parsers.mu.RUnlock()
+
+	return parsers.formats[f]
+}
+
+// registeredExtension returns the registered format of a file extension, if
+// any
+func registeredExtension(ext string) (Format, bool) {
+	parsers.mu.RLock()
+	defer parsers.mu.RUnlock()
+
+	f, ok := parsers.extensions[ext]
+	return f, ok
+}
+
+// parse converts a file of a registered format to json
+func parse(p Parser, b []byte) ([]byte, error) {
+	m, err := p.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(m)
+}
@@ -0,0 +1,86 @@
+package cfg
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// kvParser parses "key value" lines, nesting dotted keys
+var kvParser = ParserFunc(func(b []byte) (map[string]Value, error) {
+	m := map[string]Value{}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, errors.New("expected a key and value")
+		}
+
+		parts := strings.Split(key, ".")
+		node := m
+		for _, part := range parts[:len(parts)-1] {
+			if _, ok := node[part].(map[string]Value); !ok {
+				node[part] = map[string]Value{}
+			}
+			node = node[part].(map[string]Value)
+		}
+		node[parts[len(parts)-1]] = value
+	}
+
+	return m, nil
+})
+
+func init() {
+	RegisterFormat("kv", kvParser, "kv")
+}
+
+func TestRegisterFormat(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/parser/working.kv",
+		MasterPath:  "test/parser/master.kv",
+	}
+
+	if f := c.format(); f != "kv" {
+		t.Fatalf("expected=kv actual=%s", f)
+	}
+
+	r, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"db.port"}; !reflect.DeepEqual(r.Missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, r.Missing)
+	}
+
+	if len(r.Different) != 1 || r.Different[0].String() != "db.host=db.internal" {
+		t.Fatalf("expected db.host to differ actual=%v", r.Different)
+	}
+
+	// the format may be named explicitly
+	if _, err := Scan(Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", Format: "kv"}); err == nil {
+		t.Fatal("expected the env files not to parse as kv")
+	}
+}
+
+func TestRegisterFormatPanics(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		p    Parser
+	}{
+		{"kv", kvParser},
+		{"json", kvParser},
+		{"", kvParser},
+		{"other", nil},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("name=%s expected a panic", tt.name)
+				}
+			}()
+			RegisterFormat(tt.name, tt.p)
+		}()
+	}
+}
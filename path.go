@@ -0,0 +1,83 @@
+package cfg
+
+import "strings"
+
+// defaultKeySeparator joins the keys of nested json objects into a path
+const defaultKeySeparator = "."
+
+// keySeparator returns Config.KeySeparator, or the default of "."
+func (c Config) keySeparator() string {
+	if c.KeySeparator == "" {
+		return defaultKeySeparator
+	}
+
+	return c.KeySeparator
+}
+
+// keyEscaper escapes the backslashes and closing brackets of a bracketed key
+var keyEscaper = strings.NewReplacer(`\`, `\\`, `]`, `\]`)
+
+// joinKey appends a key to a path prefix, which is either empty or ends with
+// sep. a key containing sep or a bracket is bracketed, e.g. [a.b], so that
+// {"a.b": 1} and {"a": {"b": 1}} have different paths. within the brackets
+// ] and \ are escaped with a \, e.g. [x] is [[x\]]
+func joinKey(prefix, key, sep string) string {
+	if strings.Contains(key, sep) || strings.ContainsAny(key, "[]") {
+		key = "[" + keyEscaper.Replace(key) + "]"
+	}
+
+	return prefix + key
+}
+
+// splitPath splits a path into its keys, the reverse of joinKey
+func splitPath(path, sep string) []string {
+	keys := []string{}
+
+	for path != "" {
+		if key, rest, ok := bracketedKey(path, sep); ok {
+			keys = append(keys, key)
+			path = rest
+			continue
+		}
+
+		i := strings.Index(path, sep)
+		if i < 0 {
+			keys = append(keys, path)
+			break
+		}
+
+		keys = append(keys, path[:i])
+		path = path[i+len(sep):]
+	}
+
+	return keys
+}
+
+// bracketedKey unescapes the bracketed key path starts with, returning the
+// rest of the path after its separator. ok is false if path doesn't start
+// with a bracketed key followed by sep or the end of the path
+func bracketedKey(path, sep string) (key, rest string, ok bool) {
+	if !strings.HasPrefix(path, "[") {
+		return "", "", false
+	}
+
+	var b strings.Builder
+
+	for i := 1; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			b.WriteByte(path[i])
+		case path[i] == ']':
+			rest = path[i+1:]
+			if rest != "" && !strings.HasPrefix(rest, sep) {
+				return "", "", false
+			}
+			return b.String(), strings.TrimPrefix(rest, sep), true
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,145 @@
+package cfg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestJoinKey(t *testing.T) {
+	tests := []struct {
+		prefix, key, expected string
+	}{
+		{"", "a", "a"},
+		{"a.", "b", "a.b"},
+		{"", "a.b", "[a.b]"},
+		{"x.", "a.b", "x.[a.b]"},
+		{"", "[x]", `[[x\]]`},
+		{"", "[a.b]", `[[a.b\]]`},
+		{"", `a\]`, `[a\\\]]`},
+	}
+
+	for _, tt := range tests {
+		if actual := joinKey(tt.prefix, tt.key, "."); actual != tt.expected {
+			t.Errorf("expected=%s actual=%s", tt.expected, actual)
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"a", []string{"a"}},
+		{"a.b", []string{"a", "b"}},
+		{"[a.b]", []string{"a.b"}},
+		{"x.[a.b].c", []string{"x", "a.b", "c"}},
+		{`[[x\]]`, []string{"[x]"}},
+		{`[[a.b\]].c`, []string{"[a.b]", "c"}},
+		{`[a\\\]]`, []string{`a\]`}},
+		{"[x", []string{"[x"}},
+		{"[a]b.c", []string{"[a]b", "c"}},
+	}
+
+	for _, tt := range tests {
+		actual := splitPath(tt.path, ".")
+		if len(actual) != len(tt.expected) {
+			t.Fatalf("expected=%v actual=%v", tt.expected, actual)
+		}
+
+		for i := range actual {
+			if actual[i] != tt.expected[i] {
+				t.Errorf("expected=%v actual=%v", tt.expected, actual)
+			}
+		}
+	}
+}
+
+func TestJoinSplitPath(t *testing.T) {
+	for _, keys := range [][]string{{"[x]", "y"}, {"[a.b]"}, {"a", `b\`, "c.d]"}, {"[", "]"}} {
+		path := ""
+		for i, key := range keys {
+			if i > 0 {
+				path += "."
+			}
+			path = joinKey(path, key, ".")
+		}
+
+		if actual := splitPath(path, "."); !reflect.DeepEqual(actual, keys) {
+			t.Errorf("path=%s expected=%v actual=%v", path, keys, actual)
+		}
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{"db.*", "db.host", true},
+		{"[a.b].c", "[a.b].c", true},
+		{"x.[a.b].*", "x.[a.b].c", true},
+		{"*.c", "[a.b].c", true},
+		{"[a.b].c", "a.c", true},
+		{"[a.b].c", "[a.b].d", false},
+		{"[a.b].c", "[a.x].c", false},
+		{"[a.b]", "[a.b].c", false},
+	}
+
+	for _, tt := range tests {
+		if actual := matchPath([]string{tt.pattern}, tt.path, "."); actual != tt.expected {
+			t.Errorf("pattern=%s path=%s expected=%t actual=%t", tt.pattern, tt.path, tt.expected, actual)
+		}
+	}
+}
+
+func TestBracketedKeys(t *testing.T) {
+	dir := t.TempDir()
+	working, master := filepath.Join(dir, "working.json"), filepath.Join(dir, "master.json")
+
+	if err := ioutil.WriteFile(working, []byte(`{"x":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(master, []byte(`{"x":1,"[x]":2,"[a.b]":{"c":3}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{WorkingPath: working, MasterPath: master, PathStyle: PathJSONPointer}
+
+	r, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(r.Missing)
+
+	if expected := []string{"/[a.b]", "/[x]"}; !reflect.DeepEqual(expected, r.Missing) {
+		t.Fatalf("expected=%v actual=%v", expected, r.Missing)
+	}
+
+	// the missing keys are added as written, not with their brackets stripped
+	c = Config{WorkingPath: working, MasterPath: master}
+	if err := ResolveInteractive(c, strings.NewReader("a\na\n"), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(working)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{"x": 1.0, "[x]": 2.0, "[a.b]": map[string]interface{}{"c": 3.0}}
+	if !reflect.DeepEqual(expected, m) {
+		t.Fatalf("expected=%v actual=%s", expected, b)
+	}
+}
@@ -0,0 +1,100 @@
+package cfg
+
+import "strings"
+
+// PathStyle is how the json analyzer writes the paths of nested keys
+type PathStyle string
+
+const (
+	// PathDotted joins the keys of a path by Config.KeySeparator, e.g.
+	// db.pool.max
+	PathDotted PathStyle = "dotted"
+
+	// PathJSONPointer writes paths as RFC 6901 JSON Pointers, e.g.
+	// /db/pool/max, for json patch tooling
+	PathJSONPointer PathStyle = "jsonPointer"
+)
+
+// pointerEscaper escapes ~ and / within the keys of a json pointer
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// jsonPointer converts a path joined by sep to a json pointer
+func jsonPointer(path, sep string) string {
+	var b strings.Builder
+
+	for _, key := range splitPath(path, sep) {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(key))
+	}
+
+	return b.String()
+}
+
+// pointers rewrites the paths of every key found by a scan as json pointers
+func (j *jsonAnalyzer) pointers() {
+	sep := j.config.keySeparator()
+
+	pointer := func(path string) string {
+		return jsonPointer(path, sep)
+	}
+
+	paths := func(paths []string) {
+		for i, path := range paths {
+			paths[i] = pointer(path)
+		}
+	}
+
+	paths(j.missing)
+	paths(j.extra)
+	paths(j.forbidden)
+	paths(j.orderChanged)
+	paths(j.usingDefault)
+	paths(j.optional)
+
+	// warnings lead with the path of their key, e.g. "db.port: working ..." or
+	// "servers: was scalar, now array"
+	messages := func(messages []string) {
+		for i, m := range messages {
+			if n := strings.Index(m, ": "); n >= 0 {
+				messages[i] = pointer(m[:n]) + m[n:]
+			}
+		}
+	}
+
+	messages(j.typeWarnings)
+	messages(j.shapeChanged)
+
+	for i := range j.different {
+		j.different[i].Key = pointer(j.different[i].Key)
+	}
+
+	for i := range j.mismatches {
+		j.mismatches[i].Key = pointer(j.mismatches[i].Key)
+	}
+
+	if j.elements != nil {
+		elements := map[string]ElementDiff{}
+		for path, e := range j.elements {
+			elements[pointer(path)] = e
+		}
+		j.elements = elements
+	}
+
+	// result reads whether a key is required, and the master it came from,
+	// by its path
+	if j.required != nil {
+		required := map[string]bool{}
+		for path, v := range j.required {
+			required[pointer(path)] = v
+		}
+		j.required = required
+	}
+
+	if j.sources != nil {
+		sources := map[string]string{}
+		for path, source := range j.sources {
+			sources[pointer(path)] = source
+		}
+		j.sources = sources
+	}
+}
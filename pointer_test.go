@@ -0,0 +1,164 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestJsonPointer(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"db.pool.max", "/db/pool/max"},
+		{"items.0.name", "/items/0/name"},
+		{"a/b", "/a~1b"},
+		{"m~n.x", "/m~0n/x"},
+		{"~/", "/~0~1"},
+		{"[tls.cert]", "/tls.cert"},
+	}
+
+	for _, tt := range tests {
+		if actual := jsonPointer(tt.path, "."); actual != tt.expected {
+			t.Fatalf("path=%s expected=%s actual=%s", tt.path, tt.expected, actual)
+		}
+	}
+}
+
+func TestScanPathStyle(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/pointer/working.json",
+		MasterPath:  "test/pointer/master.json",
+		PathStyle:   PathJSONPointer,
+	}
+
+	r, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(r.Missing)
+
+	if expected := []string{"/a~1b", "/m~0n"}; !reflect.DeepEqual(expected, r.Missing) {
+		t.Fatalf("expected=%v actual=%v", expected, r.Missing)
+	}
+
+	if expected := []string{"/a~1b"}; !reflect.DeepEqual(expected, r.MissingRequired) {
+		t.Fatalf("expected=%v actual=%v", expected, r.MissingRequired)
+	}
+
+	if expected := []string{"/debug"}; !reflect.DeepEqual(expected, r.Extra) {
+		t.Fatalf("expected=%v actual=%v", expected, r.Extra)
+	}
+
+	keys := []string{}
+	for _, d := range r.Different {
+		keys = append(keys, d.Key)
+	}
+
+	// a key containing the separator stays one key
+	if expected := []string{"/db/pool/max", "/tls.cert"}; !reflect.DeepEqual(expected, keys) {
+		t.Fatalf("expected=%v actual=%v", expected, keys)
+	}
+
+	if _, err := Scan(Config{WorkingPath: c.WorkingPath, MasterPath: c.MasterPath, PathStyle: "slashed"}); err == nil {
+		t.Fatal("expected an unsupported path style error")
+	}
+}
+
+func TestScanPathStyleSources(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/masters/working.json",
+		MasterPaths: []string{"test/masters/base.json", "test/masters/prod.json"},
+		PathStyle:   PathJSONPointer,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.MissingSources["/region"] != "test/masters/prod.json" {
+		t.Fatalf("expected /region to be missing from prod.json actual=%+v", result.MissingSources)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Key != "/db/host" ||
+		result.Different[0].SourceFile != "test/masters/prod.json" {
+		t.Fatalf("expected /db/host to differ from prod.json actual=%+v", result.Different)
+	}
+}
+
+func TestScanPathStyleWarnings(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/strict/working.env",
+		MasterPath:   "test/strict/master.json",
+		EnvSeparator: "_",
+		StrictTypes:  true,
+		PathStyle:    PathJSONPointer,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.TypeWarnings) == 0 || !strings.HasPrefix(result.TypeWarnings[0], "/db/port: ") {
+		t.Fatalf("expected a warning for /db/port actual=%+v", result.TypeWarnings)
+	}
+}
+
+func TestScanPathStyleOptionalSections(t *testing.T) {
+	c := Config{
+		WorkingPath:      "test/optional/working.json",
+		MasterPath:       "test/optional/master.json",
+		OptionalSections: []string{"metrics", "tracing"},
+		PathStyle:        PathJSONPointer,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sections are configured as dotted paths but reported as pointers
+	if expected := []string{"/metrics"}; !reflect.DeepEqual(expected, result.OptionalSections) {
+		t.Fatalf("expected=%v actual=%v", expected, result.OptionalSections)
+	}
+}
+
+func TestScanPathStyleShapeChanged(t *testing.T) {
+	result, err := Scan(Config{WorkingPath: "test/v.json", MasterPath: "test/u.json", PathStyle: PathJSONPointer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"/endpoints: was scalar, now array", "/ports: was array, now scalar"}
+	if !reflect.DeepEqual(expected, result.ShapeChanged) {
+		t.Fatalf("expected=%v actual=%v", expected, result.ShapeChanged)
+	}
+}
+
+func TestScanPathStyleTypeMismatches(t *testing.T) {
+	c := Config{
+		WorkingPath:  "test/types/working.json",
+		MasterPath:   "test/types/master.json",
+		CompareTypes: true,
+		PathStyle:    PathJSONPointer,
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{}
+	for _, m := range result.TypeMismatches {
+		keys = append(keys, m.Key)
+	}
+
+	if expected := []string{"/cache", "/port", "/timeout"}; !reflect.DeepEqual(expected, keys) {
+		t.Fatalf("expected=%v actual=%v", expected, keys)
+	}
+}
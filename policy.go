@@ -0,0 +1,98 @@
+package cfg
+
+import "fmt"
+
+// Status is the overall outcome of evaluating a Result against a Policy
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Policy holds the thresholds used by Evaluate to decide whether a scan
+// passes. a zero Policy fails on any missing, different or forbidden key
+type Policy struct {
+	// CriticalKeys holds glob patterns of keys that fail the verdict if they
+	// are missing or different, regardless of the thresholds below
+	CriticalKeys []string
+
+	// MaxMissing and MaxDifferent fail the verdict once exceeded. negative
+	// values disable the check
+	MaxMissing   int
+	MaxDifferent int
+
+	// WarnMissing and WarnDifferent warn once exceeded. negative values
+	// disable the check
+	WarnMissing   int
+	WarnDifferent int
+}
+
+// Verdict holds the outcome of Evaluate along with the reasons behind it
+type Verdict struct {
+	Status  Status
+	Reasons []string
+	Result  *Result
+}
+
+// Evaluate scans the working and master files and judges the Result against
+// the given Policy, returning a Verdict suitable for pass/fail gating.
+// forbidden keys always fail the verdict
+func Evaluate(c Config, policy Policy) (*Verdict, error) {
+	result, err := Scan(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.evaluate(result), nil
+}
+
+// evaluate judges a Result against the Policy
+func (p Policy) evaluate(r *Result) *Verdict {
+	v := &Verdict{Status: StatusPass, Result: r}
+
+	fail := func(reason string) {
+		v.Status = StatusFail
+		v.Reasons = append(v.Reasons, reason)
+	}
+
+	warn := func(reason string) {
+		if v.Status == StatusPass {
+			v.Status = StatusWarn
+		}
+		v.Reasons = append(v.Reasons, reason)
+	}
+
+	for _, key := range r.Missing {
+		if match(p.CriticalKeys, key) {
+			fail(fmt.Sprintf("critical key %s is missing", key))
+		}
+	}
+
+	for _, d := range r.Different {
+		if match(p.CriticalKeys, d.Key) {
+			fail(fmt.Sprintf("critical key %s is different", d.Key))
+		}
+	}
+
+	if len(r.Forbidden) > 0 {
+		fail(fmt.Sprintf("%d forbidden keys found: %+v", len(r.Forbidden), r.Forbidden))
+	}
+
+	switch missing := len(r.Missing); {
+	case p.MaxMissing >= 0 && missing > p.MaxMissing:
+		fail(fmt.Sprintf("%d keys missing, more than the maximum of %d", missing, p.MaxMissing))
+	case p.WarnMissing >= 0 && missing > p.WarnMissing:
+		warn(fmt.Sprintf("%d keys missing, more than the warning threshold of %d", missing, p.WarnMissing))
+	}
+
+	switch different := len(r.Different); {
+	case p.MaxDifferent >= 0 && different > p.MaxDifferent:
+		fail(fmt.Sprintf("%d keys different, more than the maximum of %d", different, p.MaxDifferent))
+	case p.WarnDifferent >= 0 && different > p.WarnDifferent:
+		warn(fmt.Sprintf("%d keys different, more than the warning threshold of %d", different, p.WarnDifferent))
+	}
+
+	return v
+}
@@ -0,0 +1,45 @@
+package cfg
+
+import "testing"
+
+func TestPolicyEvaluate(t *testing.T) {
+	r := &Result{
+		Missing:   []string{"FOOD", "LANG", "DRINK"},
+		Different: []DiffEntry{{Key: "DB_HOST", Master: "db", Working: "localhost"}},
+	}
+
+	tests := []struct {
+		policy  Policy
+		status  Status
+		reasons int
+	}{
+		{Policy{}, StatusFail, 2},
+		{Policy{MaxMissing: -1, MaxDifferent: -1, WarnMissing: 5, WarnDifferent: -1}, StatusPass, 0},
+		{Policy{MaxMissing: 5, MaxDifferent: 5, WarnMissing: 2, WarnDifferent: 5}, StatusWarn, 1},
+		{Policy{CriticalKeys: []string{"DB_*"}, MaxMissing: -1, MaxDifferent: -1, WarnMissing: -1, WarnDifferent: -1}, StatusFail, 1},
+	}
+
+	for i, tt := range tests {
+		v := tt.policy.evaluate(r)
+		if v.Status != tt.status || len(v.Reasons) != tt.reasons {
+			t.Fatalf("test=%d expected=%s/%d actual=%s/%+v", i, tt.status, tt.reasons, v.Status, v.Reasons)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	c := Config{
+		WorkingPath:   "test/a.env",
+		MasterPath:    "test/b.env",
+		ForbiddenKeys: []string{"SPORT"},
+	}
+
+	v, err := Evaluate(c, Policy{MaxMissing: -1, MaxDifferent: -1, WarnMissing: 5, WarnDifferent: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Status != StatusFail {
+		t.Fatalf("expected=%s actual=%s", StatusFail, v.Status)
+	}
+}
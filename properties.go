@@ -0,0 +1,182 @@
+package cfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScanProperties will scan two java .properties configuration files
+// returning a slice of keys that exist in the master file and are missing in
+// the working file. dotted keys are compared as nested paths. a key that is
+// both a value and the parent of others, e.g. log4j.appender.A1=x and
+// log4j.appender.A1.layout=y, holds its own value under #value
+func ScanProperties(c Config) ([]string, error) {
+	c.Format = FormatProperties
+	return ScanJson(c)
+}
+
+// ScanPropertiesResult will scan two .properties configuration files
+// returning a Result holding every missing, extra and different key
+func ScanPropertiesResult(c Config) (*Result, error) {
+	c.Format = FormatProperties
+	return Scan(c)
+}
+
+// PrintProperties uses ScanProperties to retrieve a slice of missing keys and
+// will then print out the difference / discrepencies between the master and
+// working files
+func PrintProperties(c Config) error {
+	c.Format = FormatProperties
+	return PrintJson(c)
+}
+
+// propertyValue is the key the value of a properties key is held under when
+// other keys are nested below it
+const propertyValue = "#value"
+
+// propertiesToJson converts a .properties document to json as
+// java.util.Properties would read it, keeping the order of its keys. keys are
+// split by their dots into nested objects and every value is a string.
+// lines starting with # or ! are comments and a line ending in an odd number
+// of backslashes continues on the next. of a repeated key the last value wins
+func propertiesToJson(b []byte) ([]byte, error) {
+	root := newKvTree()
+
+	lines := logicalLines(b, func(line string) bool {
+		if propertiesComment(line) {
+			return false
+		}
+
+		n := len(line) - len(strings.TrimRight(line, `\`))
+		return n%2 == 1
+	})
+
+	for _, l := range lines {
+		line := strings.TrimLeft(l.text, " \t\f")
+		if line == "" || propertiesComment(line) {
+			continue
+		}
+
+		// the key ends at the first unescaped separator or whitespace
+		end := 0
+		for end < len(line) && !strings.ContainsRune("=: \t\f", rune(line[end])) {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end > len(line) {
+			end = len(line)
+		}
+
+		value := strings.TrimLeft(line[end:], " \t\f")
+		if value != "" && (value[0] == '=' || value[0] == ':') {
+			value = strings.TrimLeft(value[1:], " \t\f")
+		}
+
+		key, err := unescapeProperty(line[:end])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", l.number, err)
+		}
+
+		if value, err = unescapeProperty(value); err != nil {
+			return nil, fmt.Errorf("line %d: %s", l.number, err)
+		}
+
+		parts := strings.Split(key, ".")
+
+		tree := root
+		for i, part := range parts {
+			if part == "" {
+				return nil, fmt.Errorf("line %d: invalid key %s", l.number, key)
+			}
+
+			if i == len(parts)-1 {
+				err = setProperty(tree, part, value)
+			} else {
+				tree, err = propertyChild(tree, part)
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", l.number, err)
+			}
+		}
+	}
+
+	return root.json(), nil
+}
+
+// setProperty sets the value of key, under propertyValue if other keys are
+// nested below it
+func setProperty(t *kvTree, key, value string) error {
+	if child, ok := t.values[key].(*kvTree); ok {
+		return child.set(propertyValue, value)
+	}
+
+	return t.set(key, value)
+}
+
+// propertyChild returns the tree held by key, adding it if key isn't set.
+// a value already set for key is moved under propertyValue
+func propertyChild(t *kvTree, key string) (*kvTree, error) {
+	if value, ok := t.values[key].(string); ok {
+		child := newKvTree()
+		if err := child.set(propertyValue, value); err != nil {
+			return nil, err
+		}
+
+		t.values[key] = child
+		return child, nil
+	}
+
+	return t.child(key)
+}
+
+// propertiesComment determines if a line is a comment
+func propertiesComment(line string) bool {
+	line = strings.TrimLeft(line, " \t\f")
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!")
+}
+
+// unescapeProperty resolves the escapes of a key or value: \t, \n, \r, \f,
+// \uXXXX and a backslash before any other character, which stands for itself
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape %s", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %s", s[i-1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
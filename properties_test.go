@@ -0,0 +1,101 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScanProperties(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/properties/working.properties",
+		MasterPath:  "test/properties/master.properties",
+	}
+
+	missing, err := ScanProperties(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(missing)
+	if expected := []string{"db.port", "path"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	different := []string{}
+	for _, d := range result.Different {
+		different = append(different, d.String())
+	}
+
+	// the continued greeting is the same value
+	if expected := []string{"db.host=db.internal"}; !reflect.DeepEqual(different, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, different)
+	}
+}
+
+func TestScanPropertiesLog4j(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/properties/log4j/working.properties",
+		MasterPath:  "test/properties/log4j/master.properties",
+	}
+
+	result, err := ScanPropertiesResult(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A1 is both an appender and the parent of its layout
+	if expected := []string{"log4j.appender.A1.Target"}; !reflect.DeepEqual(result.Missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, result.Missing)
+	}
+
+	expected := []DiffEntry{{Key: "log4j.appender.A1.#value", Master: "org.apache.log4j.ConsoleAppender", Working: "org.apache.log4j.FileAppender"}}
+	if !reflect.DeepEqual(result.Different, expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, result.Different)
+	}
+}
+
+func TestPropertiesToJson(t *testing.T) {
+	tests := []struct {
+		properties string
+		expected   string
+	}{
+		{"", `{}`},
+		{"b=1\na:two\nc three\nd\n", `{"b":"1","a":"two","c":"three","d":""}`},
+		{"a.b = 1\na.c = 2\nd = 3", `{"a":{"b":"1","c":"2"},"d":"3"}`},
+		{"# comment \\\n! comment\na = 1 \\\n  2\r\n", `{"a":"1 2"}`},
+		// an even number of backslashes doesn't continue
+		{"a = c:\\\\\nb = 2", `{"a":"c:\\","b":"2"}`},
+		{"key\\ with\\=escapes = \\u00e9t\\u00E9\\tx", `{"key with=escapes":"été\tx"}`},
+		{"a = 1\na = 2", `{"a":"2"}`},
+		{"a = 1\na.b = 2", `{"a":{"#value":"1","b":"2"}}`},
+		{"a.b = 2\na = 1", `{"a":{"b":"2","#value":"1"}}`},
+		{"a..b = 1", ""},
+		{"a = \\u00z1", ""},
+		{"a = \\u00", ""},
+	}
+
+	for _, tt := range tests {
+		b, err := propertiesToJson([]byte(tt.properties))
+
+		if tt.expected == "" {
+			if err == nil {
+				t.Fatalf("properties=%q expected an error actual=%s", tt.properties, b)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("properties=%q %s", tt.properties, err)
+		}
+
+		if string(b) != tt.expected {
+			t.Fatalf("properties=%q expected=%s actual=%s", tt.properties, tt.expected, b)
+		}
+	}
+}
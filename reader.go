@@ -0,0 +1,134 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ScanReaders will scan a working and master config read from r rather than
+// from files, e.g. from memory, embedded files or network streams, returning
+// a Result. Config.WorkingPath and Config.MasterPath are optional and only
+// name the configs in the Result and in errors, and determine their format by
+// extension when Config.Format isn't set
+func ScanReaders(working, master io.Reader, c Config) (*Result, error) {
+	base, err := newReaderAnalyzer(working, master, c)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := base.scan(base.config.format())
+	if err != nil {
+		return nil, err
+	}
+
+	return r, base.config.strict(r)
+}
+
+// ScanJsonReaders will scan a working and master json config read from r
+// returning a slice of keys that exist in the master and are missing in the
+// working config
+func ScanJsonReaders(working, master io.Reader, c Config) ([]string, error) {
+	c.Format = FormatJson
+
+	r, err := ScanReaders(working, master, c)
+	if r == nil {
+		return nil, err
+	}
+
+	return r.Missing, err
+}
+
+// ScanEnvReaders will scan a working and master env config read from r
+// returning a slice of keys that exist in the master and are missing in the
+// working config
+func ScanEnvReaders(working, master io.Reader, c Config) ([]string, error) {
+	c.Format = FormatEnv
+
+	r, err := ScanReaders(working, master, c)
+	if r == nil {
+		return nil, err
+	}
+
+	return r.Missing, err
+}
+
+// newReaderAnalyzer returns a new analyzer loaded with the working and master
+// configs read from r
+func newReaderAnalyzer(working, master io.Reader, c Config) (*analyzer, error) {
+	if working == nil || master == nil {
+		return nil, errors.New("invalid config. both a working and master reader are required")
+	}
+
+	if c.WorkingPath == "" {
+		c.WorkingPath = "working"
+	}
+
+	if c.MasterPath == "" {
+		c.MasterPath = "master"
+	}
+
+	if err := c.validateReaders(); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	a, err := initAnalyzer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadAll(working)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s. %s", c.WorkingPath, err)
+	}
+
+	if err := a.setWorking(b, c.WorkingPath); err != nil {
+		return nil, err
+	}
+
+	if b, err = ioutil.ReadAll(master); err != nil {
+		return nil, fmt.Errorf("could not read %s. %s", c.MasterPath, err)
+	}
+
+	if err := a.setMaster(b, c.MasterPath); err != nil {
+		return nil, err
+	}
+
+	if err := a.checkConflicts(c.WorkingPath, c.MasterPath); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// validateReaders returns an error if the config fetches its files from
+// elsewhere, which can't be combined with readers
+func (c Config) validateReaders() error {
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"HostAlias", c.HostAlias != ""},
+		{"SSHHost", c.SSHHost != ""},
+		{"Container", c.Container != ""},
+		{"MasterFromEnv", c.MasterFromEnv != ""},
+		{"MasterPaths", len(c.MasterPaths) > 0},
+		{"MasterGlob", c.MasterGlob != ""},
+		{"MasterGitRef", c.MasterGitRef != ""},
+	} {
+		if option.set {
+			return fmt.Errorf("invalid config. %s can't be used with readers", option.name)
+		}
+	}
+
+	if isUrl(c.MasterPath) {
+		return errors.New("invalid config. a url MasterPath can't be used with readers")
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestScanJsonReaders(t *testing.T) {
+	working, master := strings.NewReader(`{"a": 1, "b": {"c": 2}}`), strings.NewReader(`{"a": 1, "b": {"c": 2, "d": 3}, "e": 4}`)
+
+	missing, err := ScanJsonReaders(working, master, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(missing)
+	if expected := []string{"b.d", "e"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+}
+
+func TestScanEnvReaders(t *testing.T) {
+	missing, err := ScanEnvReaders(strings.NewReader("A=1\n"), strings.NewReader("A=1\nB=2\n"), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"B"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+}
+
+func TestScanReaders(t *testing.T) {
+	working, err := os.Open("test/a.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer working.Close()
+
+	master, err := ioutil.ReadFile("test/b.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := Scan(Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ScanReaders(working, bytes.NewReader(master), Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// readers have no real path
+	expected.WorkingRealPath, expected.MasterRealPath = "", ""
+	actual.ScannedAt, actual.Duration = expected.ScannedAt, expected.Duration
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected=%+v actual=%+v", *expected, *actual)
+	}
+
+	// the names determine the format
+	r, err := ScanReaders(strings.NewReader("a: 1\n"), strings.NewReader("a: 1\nb: 2\n"), Config{WorkingPath: "config.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"b"}; !reflect.DeepEqual(r.Missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, r.Missing)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestScanReadersErrors(t *testing.T) {
+	tests := []struct {
+		working, master *strings.Reader
+		c               Config
+		err             string
+	}{
+		{strings.NewReader(""), strings.NewReader(""), Config{HostAlias: "remote"}, "invalid config. HostAlias can't be used with readers"},
+		{strings.NewReader(""), strings.NewReader(""), Config{MasterPath: "https://example.com/.env"}, "invalid config. a url MasterPath can't be used with readers"},
+		{strings.NewReader("{"), strings.NewReader("{}"), Config{Format: FormatJson}, ""},
+		{strings.NewReader("A=1\n<<<<<<< HEAD\n"), strings.NewReader("A=1\n"), Config{}, ""},
+	}
+
+	for _, tt := range tests {
+		_, err := ScanReaders(tt.working, tt.master, tt.c)
+		if err == nil {
+			t.Fatalf("config=%+v expected an error", tt.c)
+		}
+		if tt.err != "" && err.Error() != tt.err {
+			t.Fatalf("expected=%s actual=%s", tt.err, err)
+		}
+	}
+
+	_, err := ScanReaders(errReader{}, strings.NewReader(""), Config{})
+	if expected := "could not read working. connection reset"; err == nil || err.Error() != expected {
+		t.Fatalf("expected=%s actual=%v", expected, err)
+	}
+
+	if _, err := ScanReaders(nil, strings.NewReader(""), Config{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,179 @@
+package cfg
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// redacted replaces the values of sensitive keys
+const redacted = "*****"
+
+// defaultRedactPatterns are used when Config.RedactPatterns is nil
+var defaultRedactPatterns = []string{"PASSWORD", "SECRET", "TOKEN", "KEY"}
+
+// redactPatterns returns Config.RedactPatterns, or the defaults if it's nil
+func (c Config) redactPatterns() []string {
+	if c.RedactPatterns == nil {
+		return defaultRedactPatterns
+	}
+
+	return c.RedactPatterns
+}
+
+// sensitive returns whether the value of key must be redacted, a redact
+// pattern being the first or last words of the key, or of one of its nested
+// keys, ignoring case. words are separated by _, - and . or start at an upper
+// case letter, so KEY matches API_KEY, apiKey and KEY_ID but not MONKEY,
+// KEYCLOAK_URL or PRIMARY_KEY_COLUMN. a trailing s is allowed, e.g. secrets
+func (c Config) sensitive(key string) bool {
+	for _, pattern := range c.redactPatterns() {
+		p := keyWords(pattern)
+		if len(p) == 0 {
+			continue
+		}
+
+		for _, k := range splitPath(key, c.keySeparator()) {
+			w := keyWords(k)
+			if len(w) >= len(p) && (equalWords(w[:len(p)], p) || equalWords(w[len(w)-len(p):], p)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// keyWords splits a key into its lower case words, e.g. DB_PASSWORD and
+// dbPassword into db and password
+func keyWords(key string) []string {
+	words := []string{}
+
+	start, prev := 0, rune(0)
+	for i, r := range key {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			if i > start {
+				words = append(words, key[start:i])
+			}
+			start = i + 1
+		case unicode.IsUpper(r) && i > start && !unicode.IsUpper(prev):
+			words = append(words, key[start:i])
+			start = i
+		}
+		prev = r
+	}
+
+	if start < len(key) {
+		words = append(words, key[start:])
+	}
+
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+
+	return words
+}
+
+// equalWords returns whether the words of a key are the words of a pattern,
+// allowing the last to be plural
+func equalWords(words, pattern []string) bool {
+	for i := range pattern {
+		if words[i] != pattern[i] && (i < len(pattern)-1 || words[i] != pattern[i]+"s") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// redactValue returns the value of key, or ***** if key is sensitive. empty
+// values are kept, as they hide nothing
+func (c Config) redactValue(key, value string) string {
+	if value == "" || !c.sensitive(key) {
+		return value
+	}
+
+	return redacted
+}
+
+// redactValues redacts the values of the sensitive keys of m
+func (c Config) redactValues(m map[string]string) {
+	for key, value := range m {
+		m[key] = c.redactValue(key, value)
+	}
+}
+
+// redact returns the entry with its values redacted if its key is sensitive
+func (c Config) redact(d DiffEntry) DiffEntry {
+	if !c.sensitive(d.Key) {
+		return d
+	}
+
+	d.Master, d.Working = c.redactValue(d.Key, d.Master), c.redactValue(d.Key, d.Working)
+
+	return d
+}
+
+// redactJson returns a copy of the json value at path with the values of the
+// sensitive keys nested within it redacted, so e.g. the password of an object
+// in an array doesn't leak when the array is reported as a whole
+func (c Config) redactJson(path string, v interface{}) interface{} {
+	sep := c.keySeparator()
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, value := range t {
+			key := joinKey(path+sep, k, sep)
+			if value != nil && value != "" && c.sensitive(key) {
+				out[k] = redacted
+				continue
+			}
+
+			out[k] = c.redactJson(key, value)
+		}
+
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, value := range t {
+			out[i] = c.redactJson(path+sep+strconv.Itoa(i), value)
+		}
+
+		return out
+	}
+
+	return v
+}
+
+// redactLine returns a malformed line with the text after what would be its
+// key redacted, as whether it holds a secret can't be told without a key,
+// e.g. DB_PASSWORD hunter2 as DB_PASSWORD *****. lines are kept as they are
+// when redaction is turned off
+func (c Config) redactLine(line string) string {
+	if c.RedactPatterns != nil && len(c.RedactPatterns) == 0 {
+		return line
+	}
+
+	end := strings.IndexAny(line, "= \t")
+	if end < 0 || strings.TrimSpace(line[end+1:]) == "" {
+		return line
+	}
+
+	return line[:end+1] + redacted
+}
+
+// redactAll returns a redacted value in place of each of values
+func redactAll(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	out := make([]string, len(values))
+	for i := range out {
+		out[i] = redacted
+	}
+
+	return out
+}
@@ -0,0 +1,224 @@
+package cfg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRedactJson(t *testing.T) {
+	c := Config{
+		WorkingPath:    "test/redact/working.json",
+		MasterPath:     "test/redact/master.json",
+		RedactPatterns: []string{"PASSWORD", "token"},
+		ArrayModes:     map[string]ArrayMode{"api.token": ArraySet},
+	}
+
+	r, err := ScanJsonResult(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Slice(r.Different, func(i, j int) bool { return r.Different[i].Key < r.Different[j].Key })
+
+	expected := []DiffEntry{
+		{Key: "api.token", Master: redacted, Working: redacted},
+		{Key: "database.host", Master: "localhost", Working: "db.internal"},
+		{Key: "database.password", Master: redacted, Working: redacted},
+		{Key: "port", Master: "80", Working: "8080"},
+		{Key: "users", Master: `[{"name":"admin","password":"*****"}]`, Working: `[{"name":"admin","password":"*****"},{"apiKey":"s3cret","name":"ci"}]`},
+	}
+
+	if !reflect.DeepEqual(expected, r.Different) {
+		t.Fatalf("expected=%+v actual=%+v", expected, r.Different)
+	}
+
+	elements := ElementDiff{Added: []string{redacted}, Removed: []string{}}
+	if !reflect.DeepEqual(elements, r.ElementChanges["api.token"]) {
+		t.Fatalf("expected=%+v actual=%+v", elements, r.ElementChanges)
+	}
+}
+
+func TestSensitive(t *testing.T) {
+	c := Config{RedactPatterns: []string{"secret", ""}}
+
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{"CLIENT_SECRET", true},
+		{"vault.secrets.path", true},
+		{"CLIENT_ID", false},
+		{"clientSecret", true},
+		{"SECRETARY", false},
+	}
+
+	for _, tt := range tests {
+		if actual := c.sensitive(tt.key); actual != tt.expected {
+			t.Fatalf("key=%s expected=%t actual=%t", tt.key, tt.expected, actual)
+		}
+	}
+
+	// the default patterns match whole words, not parts of them
+	c = Config{}
+
+	tests = []struct {
+		key      string
+		expected bool
+	}{
+		{"API_KEY", true},
+		{"apiKey", true},
+		{"KEY_ID", true},
+		{"SECRET_KEY_BASE", true},
+		{"aws.access-key", true},
+		{"auth.tokens.github", true},
+		{"MONKEY", false},
+		{"KEYCLOAK_URL", false},
+		{"PRIMARY_KEY_COLUMN", false},
+		{"keystore.path", false},
+		{"TOKENIZER", false},
+	}
+
+	for _, tt := range tests {
+		if actual := c.sensitive(tt.key); actual != tt.expected {
+			t.Fatalf("key=%s expected=%t actual=%t", tt.key, tt.expected, actual)
+		}
+	}
+}
+
+func TestRedactTypeWarnings(t *testing.T) {
+	dir := t.TempDir()
+	working, master := filepath.Join(dir, "working.json"), filepath.Join(dir, "master.json")
+
+	if err := ioutil.WriteFile(working, []byte(`{"db":{"password":"93812","port":"5432"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(master, []byte(`{"db":{"password":93812,"port":5432}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Scan(Config{WorkingPath: working, MasterPath: master, StrictTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"db.password: working string ***** compared to master number *****",
+		`db.port: working string "5432" compared to master number 5432`,
+	}
+
+	if !reflect.DeepEqual(expected, r.TypeWarnings) {
+		t.Fatalf("expected=%+v actual=%+v", expected, r.TypeWarnings)
+	}
+
+	if strings.Contains(r.Diff(), "93812") {
+		t.Fatalf("expected the password to be redacted actual=%s", r.Diff())
+	}
+}
+
+func TestRedactMalformed(t *testing.T) {
+	dir := t.TempDir()
+	working, master := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.example")
+
+	if err := ioutil.WriteFile(working, []byte("HOST=db.internal\nDB_PASSWORD hunter2\nAUTH_TOKEN=\"s3cret\nJUST_A_KEY\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(master, []byte("HOST=localhost\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	c := Config{WorkingPath: working, MasterPath: master, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+
+	r, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{working + ":2: DB_PASSWORD *****", working + ":3: AUTH_TOKEN=*****", working + ":4: JUST_A_KEY"}
+	if !reflect.DeepEqual(expected, r.Malformed) {
+		t.Fatalf("expected=%+v actual=%+v", expected, r.Malformed)
+	}
+
+	out := captureStdout(t, func() { err = PrintEnv(c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"hunter2", "s3cret"} {
+		if strings.Contains(out, secret) || strings.Contains(logs.String(), secret) {
+			t.Fatalf("expected %s to be redacted actual=%s%s", secret, out, logs.String())
+		}
+	}
+
+	// an empty slice turns redaction off
+	c.RedactPatterns = []string{}
+	if r, err = Scan(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := r.Malformed[0]; actual != working+":2: DB_PASSWORD hunter2" {
+		t.Fatalf("expected the line as it is actual=%s", actual)
+	}
+}
+
+func TestRedactDefaults(t *testing.T) {
+	dir := t.TempDir()
+	working, master := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.example")
+
+	if err := ioutil.WriteFile(working, []byte("DB_PASSWORD=hunter2\nAPI_KEY=\nHOST=db.internal\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(master, []byte("DB_PASSWORD=changeme\nAPI_KEY=abc\nHOST=localhost\nAUTH_TOKEN=xyz\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{WorkingPath: working, MasterPath: master}
+
+	r, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []DiffEntry{
+		{Key: "DB_PASSWORD", Master: redacted, Working: redacted},
+		{Key: "API_KEY", Master: redacted, Working: ""},
+		{Key: "HOST", Master: "localhost", Working: "db.internal"},
+	}
+
+	if !reflect.DeepEqual(expected, r.Different) {
+		t.Fatalf("expected=%+v actual=%+v", expected, r.Different)
+	}
+
+	out := captureStdout(t, func() { err = PrintEnv(c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var csv bytes.Buffer
+	if err := PrintCSV(c, &csv); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"hunter2", "changeme", "abc", "xyz"} {
+		if strings.Contains(out, secret) || strings.Contains(csv.String(), secret) {
+			t.Fatalf("expected %s to be redacted actual=%s %s", secret, out, csv.String())
+		}
+	}
+
+	// an empty slice redacts nothing
+	c.RedactPatterns = []string{}
+
+	if r, err = Scan(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Different[0].Working != "hunter2" {
+		t.Fatalf("expected=hunter2 actual=%s", r.Different[0].Working)
+	}
+}
@@ -0,0 +1,184 @@
+package cfg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// refResolver inlines json references, objects of the form
+// {"$ref": "other.json#/path"}, within a parsed json document. yaml files
+// are read with their includes as references
+type refResolver struct {
+	// docs holds the documents parsed so far, keyed by path
+	docs map[string]interface{}
+
+	// active holds the references being followed, to detect cycles
+	active map[string]bool
+
+	// files holds the sha256 of each referenced file read, if not nil
+	files refFiles
+}
+
+// refFiles holds the sha256 of the content of referenced files, keyed by
+// path, so a document resolved from them can be cached until one changes
+type refFiles map[string][sha256.Size]byte
+
+// changed determines if any of the files no longer has the content it had
+// when resolved, or can't be read
+func (f refFiles) changed() bool {
+	for path, sum := range f {
+		b, err := ioutil.ReadFile(path)
+		if err != nil || sha256.Sum256(b) != sum {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveRefs returns the json document parsed from path with every
+// reference inlined. referenced files are read relative to the file that
+// references them and a bare "#/path" refers to the same file. each file
+// read is stored in files, if given
+func resolveRefs(doc interface{}, path string, files refFiles) (interface{}, error) {
+	r := refResolver{
+		docs:   map[string]interface{}{filepath.Clean(path): doc},
+		active: map[string]bool{},
+		files:  files,
+	}
+
+	return r.resolve(doc, filepath.Clean(path))
+}
+
+// resolve inlines the references within a value of the document at path
+func (r *refResolver) resolve(v interface{}, path string) (interface{}, error) {
+	var err error
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := t["$ref"].(string); ok && len(t) == 1 {
+			return r.follow(ref, path)
+		}
+
+		for k := range t {
+			if t[k], err = r.resolve(t[k], path); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i := range t {
+			if t[i], err = r.resolve(t[i], path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// follow returns the resolved value a reference made from the document at
+// path points to
+func (r *refResolver) follow(ref, path string) (interface{}, error) {
+	file, pointer := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		file, pointer = ref[:i], ref[i+1:]
+	}
+
+	if file != "" {
+		file = filepath.Join(filepath.Dir(path), file)
+	} else {
+		file = path
+	}
+
+	id := file + "#" + pointer
+	if r.active[id] {
+		return nil, fmt.Errorf("could not resolve %s in %s. reference cycle", ref, path)
+	}
+
+	r.active[id] = true
+	defer delete(r.active, id)
+
+	doc, err := r.load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := lookupPointer(doc, pointer)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve %s in %s. %s", ref, path, err)
+	}
+
+	return r.resolve(v, file)
+}
+
+// load returns the parsed json document at path, reading it the first time
+func (r *refResolver) load(path string) (interface{}, error) {
+	if doc, ok := r.docs[path]; ok {
+		return doc, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s. %s", path, err)
+	}
+
+	if r.files != nil {
+		r.files[path] = sha256.Sum256(b)
+	}
+
+	// included yaml files may include others in turn
+	if formatOf(path) == FormatYaml {
+		if b, err = yamlIncludesToJson(b); err != nil {
+			return nil, fmt.Errorf("could not parse %s. %s", path, err)
+		}
+	}
+
+	var doc interface{}
+	if err := unmarshalJson(b, &doc); err != nil {
+		return nil, fmt.Errorf("could not parse %s. %s", path, err)
+	}
+
+	r.docs[path] = doc
+
+	return doc, nil
+}
+
+// lookupPointer returns the value a json pointer (e.g. /db/hosts/0) points to
+// within a document. an empty pointer is the whole document
+func lookupPointer(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" || pointer == "/" {
+		return doc, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer %s", pointer)
+	}
+
+	v := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		switch t := v.(type) {
+		case map[string]interface{}:
+			next, ok := t[token]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+
+	return v, nil
+}
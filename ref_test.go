@@ -0,0 +1,35 @@
+package cfg
+
+import "testing"
+
+func TestLookupPointer(t *testing.T) {
+	doc := map[string]interface{}{
+		"db":  map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+		"a/b": "slash",
+	}
+
+	tests := []struct {
+		pointer  string
+		expected interface{}
+	}{
+		{"/db/hosts/1", "b"},
+		{"/a~1b", "slash"},
+	}
+
+	for _, tt := range tests {
+		v, err := lookupPointer(doc, tt.pointer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v != tt.expected {
+			t.Fatalf("pointer=%s expected=%v actual=%v", tt.pointer, tt.expected, v)
+		}
+	}
+
+	for _, pointer := range []string{"/db/hosts/2", "/nope", "db"} {
+		if _, err := lookupPointer(doc, pointer); err == nil {
+			t.Fatalf("expected an error for %s", pointer)
+		}
+	}
+}
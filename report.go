@@ -0,0 +1,67 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ReportFormat is the format of a report written by WriteReport
+type ReportFormat string
+
+const (
+	// ReportJson is the Result as indented json
+	ReportJson ReportFormat = "json"
+
+	// ReportCSV is one row per discrepancy, as written by PrintCSV
+	ReportCSV ReportFormat = "csv"
+
+	// ReportText is the categorized text report of Result.Diff
+	ReportText ReportFormat = "text"
+)
+
+// WriteReport writes a Result to the file at path in the given format, e.g.
+// to archive it as a CI artifact. an empty format is inferred from the path's
+// extension, .json, .csv or .txt
+func WriteReport(r *Result, path string, format ReportFormat) error {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			format = ReportJson
+		case ".csv":
+			format = ReportCSV
+		case ".txt":
+			format = ReportText
+		default:
+			return fmt.Errorf("could not infer a report format from %s", path)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case ReportJson:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		buf.Write(append(b, '\n'))
+	case ReportCSV:
+		if err := writeCSV(&buf, r, nil, nil); err != nil {
+			return err
+		}
+	case ReportText:
+		buf.WriteString(r.Diff())
+	default:
+		return fmt.Errorf("unsupported report format %s", format)
+	}
+
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write %s. %s", path, err)
+	}
+
+	return nil
+}
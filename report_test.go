@@ -0,0 +1,68 @@
+package cfg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	r := &Result{
+		WorkingPath: ".env",
+		MasterPath:  ".env.example",
+		Missing:     []string{"NAME"},
+		Different:   []DiffEntry{{Key: "FRUIT", Master: "Mango", Working: "Guava"}},
+	}
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		format   ReportFormat
+		expected string
+	}{
+		{"report.csv", "", "key,category,masterValue,workingValue\nNAME,missing,,\nFRUIT,different,Mango,Guava\n"},
+		{"report.txt", "", ".env compared to .env.example\nmissing (1):\n  NAME\ndifferent (1):\n  FRUIT: Mango -> Guava\n"},
+		{"report.out", ReportCSV, "key,category,masterValue,workingValue\nNAME,missing,,\nFRUIT,different,Mango,Guava\n"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := WriteReport(r, path, tt.format); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != tt.expected {
+			t.Fatalf("expected=%s actual=%s", tt.expected, b)
+		}
+	}
+
+	path := filepath.Join(dir, "report.json")
+	if err := WriteReport(r, path, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Result
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded.Missing) != 1 || decoded.Different[0].Working != "Guava" {
+		t.Fatalf("expected the result to round trip actual=%+v", decoded)
+	}
+
+	if err := WriteReport(r, filepath.Join(dir, "report"), ""); err == nil {
+		t.Fatal("expected an error for a path without a known extension")
+	}
+}
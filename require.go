@@ -0,0 +1,64 @@
+package cfg
+
+import (
+	"bytes"
+	"strings"
+)
+
+// RequireKeys checks the working file against a manifest of keys that must
+// exist, returning the required keys that are missing. nested json keys are
+// given as dotted paths, e.g. database.replica.host
+func RequireKeys(c Config, required []string) ([]string, error) {
+	a, err := initAnalyzer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.readWorking(c.WorkingPath); err != nil {
+		return nil, err
+	}
+
+	keys, err := a.workingKeys(c.format())
+	if err != nil {
+		return nil, err
+	}
+
+	missing := []string{}
+	for _, key := range required {
+		if !keys[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing, nil
+}
+
+// workingKeys parses the working file returning its keys
+func (a analyzer) workingKeys(format Format) (map[string]bool, error) {
+	return keysOf(a.working, format, a.config.keySeparator())
+}
+
+// keysOf parses config file bytes of the given format returning its keys.
+// nested json keys are returned as paths joined by sep
+func keysOf(b []byte, format Format, sep string) (map[string]bool, error) {
+	keys := map[string]bool{}
+
+	if format.nested() {
+		if err := flattenStream(bytes.NewReader(b), sep, keys); err != nil {
+			return nil, err
+		}
+
+		return keys, nil
+	}
+
+	env, err := envAnalyzer{}.unmarshal(strings.Split(string(b), "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range env {
+		keys[e.Key] = true
+	}
+
+	return keys, nil
+}
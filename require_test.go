@@ -0,0 +1,33 @@
+package cfg
+
+import "testing"
+
+func TestRequireKeys(t *testing.T) {
+	tests := []struct {
+		path     string
+		required []string
+		missing  []string
+	}{
+		{"test/a.env", []string{"FRUIT", "SPORT"}, []string{}},
+		{"test/a.env", []string{"FRUIT", "FOOD", "LANG"}, []string{"FOOD", "LANG"}},
+		{"test/a.json", []string{"1", "3", "3.4"}, []string{}},
+		{"test/a.json", []string{"3.4", "3.5", "6"}, []string{"3.5", "6"}},
+	}
+
+	for _, tt := range tests {
+		missing, err := RequireKeys(Config{WorkingPath: tt.path}, tt.required)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(missing) != len(tt.missing) {
+			t.Fatalf("path=%s expected=%+v actual=%+v", tt.path, tt.missing, missing)
+		}
+
+		for i := range missing {
+			if missing[i] != tt.missing[i] {
+				t.Fatalf("path=%s expected=%s actual=%s", tt.path, tt.missing[i], missing[i])
+			}
+		}
+	}
+}
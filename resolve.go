@@ -0,0 +1,329 @@
+package cfg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// errNotTerminal is returned by ResolveInteractive when its input isn't a
+// terminal and Config.ResolveAll isn't set
+var errNotTerminal = errors.New("input is not a terminal. set ResolveAll to add every missing key")
+
+// resolution is a key missing from the working file and its master value.
+// typed holds the master value of a json key as parsed, and edited whether
+// value was entered in its place
+type resolution struct {
+	key    string
+	value  string
+	typed  interface{}
+	edited bool
+}
+
+// ResolveInteractive prompts on out for each key missing from the working
+// file, showing its master value and reading from in whether to add it, skip
+// it or edit its value first. the accepted keys are written to the working
+// file, or only printed if Config.DryRun is set. when in is a file that isn't
+// a terminal every missing key is added if Config.ResolveAll is set,
+// otherwise an error is returned
+func ResolveInteractive(c Config, in io.Reader, out io.Writer) error {
+	// the working file is rewritten from what was parsed, so it must be
+	// parsed as is
+	if c.RootPath != "" || c.KeyTransform != nil || c.EnvSeparator != "" {
+		return errors.New("can't resolve with RootPath, KeyTransform or EnvSeparator set")
+	}
+
+	// the working file is written locally as read, so an encrypted working
+	// file would be overwritten in plain text, and one read from a container
+	// or a directory would be written somewhere it wasn't read from
+	if c.DecryptFunc != nil || c.Container != "" {
+		return errors.New("can't resolve with DecryptFunc or Container set")
+	}
+
+	if formatOf(c.WorkingPath) == FormatDir {
+		return fmt.Errorf("can't resolve the directory %s", c.WorkingPath)
+	}
+
+	// yaml, toml, ini, properties, xml and hcl are parsed as json, so can't be
+	// written back as they were
+	if c.format().nested() && c.format() != FormatJson {
+		return fmt.Errorf("can't resolve %s files", c.format())
+	}
+
+	if c.format() == FormatJson {
+		j, err := newJsonAnalyzer(c)
+		if err != nil {
+			return err
+		}
+
+		master, working := map[string]interface{}{}, map[string]interface{}{}
+		sep := c.keySeparator()
+		leafValues(j.jsonMaster, "", sep, master)
+		leafValues(j.jsonWorking, "", sep, working)
+
+		missing := []resolution{}
+		for path, value := range master {
+			if _, ok := working[path]; !ok {
+				missing = append(missing, resolution{key: path, value: rawJson(value), typed: value})
+			}
+		}
+
+		sort.Slice(missing, func(i, k int) bool { return missing[i].key < missing[k].key })
+
+		accepted, err := promptMissing(c, missing, in, out)
+		if err != nil || len(accepted) == 0 {
+			return err
+		}
+
+		// the working file is rewritten as written rather than as compared,
+		// keeping its order and any keys ignored by the comparison
+		rewritten := map[string]interface{}{}
+		if err := unmarshalJson(j.working, &rewritten); err != nil {
+			return err
+		}
+
+		order := map[string][]string{}
+		if err := keyOrder(j.working, sep, order, nil); err != nil {
+			return err
+		}
+
+		for _, r := range accepted {
+			c.dryRun("would add %s=%s", r.key, c.redactValue(r.key, r.value))
+
+			// master values are kept as they are, so "5432" stays a string,
+			// and only edited values are parsed
+			value := r.typed
+			if r.edited {
+				value = jsonValue(r.value)
+			}
+
+			setPath(rewritten, splitPath(r.key, sep), value)
+		}
+
+		var b bytes.Buffer
+		if err := writeOrdered(&b, rewritten, order, "", sep, ""); err != nil {
+			return err
+		}
+
+		return writeWorking(c, append(b.Bytes(), '\n'))
+	}
+
+	e, err := newEnvAnalyzer(c)
+	if err != nil {
+		return err
+	}
+
+	e.scan()
+
+	master := map[string]string{}
+	for _, m := range e.envMaster {
+		master[m.Key] = m.Value
+	}
+
+	missing := []resolution{}
+	for _, key := range e.missing {
+		missing = append(missing, resolution{key: key, value: master[key]})
+	}
+
+	accepted, err := promptMissing(c, missing, in, out)
+	if err != nil || len(accepted) == 0 {
+		return err
+	}
+
+	b := e.working
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		b = append(b, '\n')
+	}
+
+	for _, r := range accepted {
+		c.dryRun("would add %s=%s", r.key, c.redactValue(r.key, r.value))
+		b = append(b, fmt.Sprintf("%s=%s\n", r.key, r.value)...)
+	}
+
+	return writeWorking(c, b)
+}
+
+// promptMissing runs the prompt loop returning the accepted keys, with any
+// edited values. reaching the end of in skips the remaining keys
+func promptMissing(c Config, missing []resolution, in io.Reader, out io.Writer) ([]resolution, error) {
+	if !isTerminal(in) {
+		if !c.ResolveAll {
+			return nil, errNotTerminal
+		}
+		return missing, nil
+	}
+
+	accepted := []resolution{}
+	scanner := bufio.NewScanner(in)
+
+	// ask reads the answer to a question, returning false at the end of in
+	ask := func(format string, args ...interface{}) (string, bool) {
+		fmt.Fprintf(out, format, args...)
+		if !scanner.Scan() {
+			return "", false
+		}
+		return scanner.Text(), true
+	}
+
+loop:
+	for _, r := range missing {
+		for {
+			answer, ok := ask("%s is missing (master value: %s). [a]dd, [s]kip or [e]dit? ", r.key, c.redactValue(r.key, r.value))
+			if !ok {
+				break loop
+			}
+
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "a", "add":
+				accepted = append(accepted, r)
+			case "s", "skip":
+			case "e", "edit":
+				value, ok := ask("value for %s: ", r.key)
+				if !ok {
+					break loop
+				}
+				accepted = append(accepted, resolution{key: r.key, value: value, edited: true})
+			default:
+				continue
+			}
+
+			break
+		}
+	}
+
+	fmt.Fprintf(out, "adding %d of %d missing keys to %s\n", len(accepted), len(missing), c.WorkingPath)
+
+	return accepted, scanner.Err()
+}
+
+// isTerminal determines if in is interactive. readers that aren't files, e.g.
+// one driven by an embedding program, are treated as interactive
+func isTerminal(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	if !ok {
+		return true
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// setPath sets the value at the path, given as its keys, of a map, creating
+// nested maps as needed
+func setPath(m map[string]interface{}, parts []string, value interface{}) {
+	for _, part := range parts[:len(parts)-1] {
+		if _, ok := m[part].(map[string]interface{}); !ok {
+			m[part] = map[string]interface{}{}
+		}
+		m = m[part].(map[string]interface{})
+	}
+
+	m[parts[len(parts)-1]] = value
+}
+
+// leafValues stores each value of a nested map that isn't a map itself in
+// values, keyed by its path joined by sep
+func leafValues(m map[string]interface{}, prefix, sep string, values map[string]interface{}) {
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			leafValues(nested, joinKey(prefix, k, sep)+sep, sep, values)
+			continue
+		}
+
+		values[joinKey(prefix, k, sep)] = v
+	}
+}
+
+// writeOrdered writes m as indented json, with the keys of each object in
+// the order stored by keyOrder for its path prefix. keys without an order,
+// e.g. those just added, follow in sorted order
+func writeOrdered(b *bytes.Buffer, m map[string]interface{}, order map[string][]string, prefix, sep, indent string) error {
+	keys, seen := []string{}, map[string]bool{}
+	for _, k := range order[prefix] {
+		if _, ok := m[k]; ok && !seen[k] {
+			keys, seen[k] = append(keys, k), true
+		}
+	}
+
+	rest := []string{}
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+
+	b.WriteString("{")
+	for i, k := range append(keys, rest...) {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		key, _ := json.Marshal(k)
+		fmt.Fprintf(b, "\n%s  %s: ", indent, key)
+
+		if nested, ok := m[k].(map[string]interface{}); ok {
+			if err := writeOrdered(b, nested, order, joinKey(prefix, k, sep)+sep, sep, indent+"  "); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := json.MarshalIndent(m[k], indent+"  ", "  ")
+		if err != nil {
+			return err
+		}
+		b.Write(value)
+	}
+
+	if len(m) > 0 {
+		b.WriteString("\n" + indent)
+	}
+	b.WriteString("}")
+
+	return nil
+}
+
+// jsonValue parses a value as json, falling back to the value as a string
+func jsonValue(value string) interface{} {
+	var v interface{}
+	if err := unmarshalJson([]byte(value), &v); err != nil {
+		return value
+	}
+
+	return v
+}
+
+// writeWorking replaces the contents of the working file, keeping its mode
+func writeWorking(c Config, b []byte) error {
+	path, err := resolve(c.WorkingPath)
+	if err != nil {
+		return fmt.Errorf("could not open %s. %s", c.WorkingPath, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("could not open %s. %s", c.WorkingPath, err)
+	}
+
+	if c.dryRun("would write %d bytes to %s", len(b), c.WorkingPath) {
+		return nil
+	}
+
+	if err := ioutil.WriteFile(path, b, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("could not write %s. %s", c.WorkingPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package cfg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyFixture copies a fixture into a temp dir so it can be written to
+func copyFixture(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), filepath.Base(path))
+	if err := ioutil.WriteFile(dst, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dst
+}
+
+func TestResolveInteractiveEnv(t *testing.T) {
+	c := Config{
+		WorkingPath: copyFixture(t, "test/a.env"),
+		MasterPath:  "test/b.env",
+	}
+
+	keys, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add the first, skip the second after an unknown answer and edit the third
+	answers := []string{"a", "?", "s", "e", "edited"}
+
+	var out bytes.Buffer
+	if err := ResolveInteractive(c, strings.NewReader(strings.Join(answers, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(c.WorkingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	working, err := ParseEnv(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := working[keys[0]]; !ok {
+		t.Fatalf("expected %s to be added actual=%s", keys[0], b)
+	}
+
+	if _, ok := working[keys[1]]; ok {
+		t.Fatalf("expected %s to be skipped actual=%s", keys[1], b)
+	}
+
+	if working[keys[2]] != "edited" {
+		t.Fatalf("expected %s to be edited actual=%s", keys[2], b)
+	}
+}
+
+func TestResolveInteractiveJson(t *testing.T) {
+	c := Config{
+		WorkingPath: copyFixture(t, "test/root/working.json"),
+		MasterPath:  "test/root/master.json",
+	}
+
+	// db.host, logging.format
+	var out bytes.Buffer
+	if err := ResolveInteractive(c, strings.NewReader("e\n\"db.internal\"\na\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 0 {
+		t.Fatalf("expected no missing keys actual=%+v", result.Missing)
+	}
+}
+
+func TestResolveInteractiveJsonValues(t *testing.T) {
+	c := Config{
+		WorkingPath: copyFixture(t, "test/resolve/working.json"),
+		MasterPath:  "test/resolve/master.json",
+	}
+
+	// add db.port, edit debug
+	var out bytes.Buffer
+	if err := ResolveInteractive(c, strings.NewReader("a\ne\nfalse\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(c.WorkingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the master string "5432" stays a string, the edited value is parsed and
+	// the working file keeps its order
+	expected := `{
+  "zone": "us",
+  "name": "app",
+  "db": {
+    "host": "localhost",
+    "port": "5432"
+  },
+  "debug": false
+}
+`
+
+	if string(b) != expected {
+		t.Fatalf("expected=%s actual=%s", expected, b)
+	}
+}
+
+func TestResolveInteractiveNotTerminal(t *testing.T) {
+	c := Config{
+		WorkingPath: copyFixture(t, "test/a.env"),
+		MasterPath:  "test/b.env",
+	}
+
+	// a regular file, as when input is redirected
+	f, err := os.Open(copyFixture(t, "test/a.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := ResolveInteractive(c, f, ioutil.Discard); err != errNotTerminal {
+		t.Fatalf("expected=%s actual=%v", errNotTerminal, err)
+	}
+
+	c.ResolveAll = true
+	if err := ResolveInteractive(c, f, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 0 {
+		t.Fatalf("expected every missing key to be added actual=%+v", keys)
+	}
+}
+
+func TestResolveInteractiveDryRun(t *testing.T) {
+	var out bytes.Buffer
+
+	c := Config{
+		WorkingPath:  copyFixture(t, "test/a.env"),
+		MasterPath:   "test/b.env",
+		ResolveAll:   true,
+		DryRun:       true,
+		DryRunOutput: &out,
+	}
+
+	before, err := ioutil.ReadFile(c.WorkingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(copyFixture(t, "test/a.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := ResolveInteractive(c, f, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ioutil.ReadFile(c.WorkingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(before, after) {
+		t.Fatal("expected the working file to be unchanged")
+	}
+
+	if !strings.Contains(out.String(), "dry run: would add ") || !strings.Contains(out.String(), "dry run: would write ") {
+		t.Fatalf("expected the changes to be printed actual=%s", out.String())
+	}
+}
+
+func TestResolveInteractiveUnsupported(t *testing.T) {
+	working := copyFixture(t, "test/a.env")
+	decrypt := func(b []byte) ([]byte, error) { return b, nil }
+
+	tests := []struct {
+		c        Config
+		expected string
+	}{
+		{Config{WorkingPath: working, MasterPath: "test/b.env", DecryptFunc: decrypt}, "can't resolve with DecryptFunc or Container set"},
+		{Config{WorkingPath: working, MasterPath: "test/b.env", Container: "app"}, "can't resolve with DecryptFunc or Container set"},
+		{Config{WorkingPath: "test/multiline/secrets", MasterPath: "test/b.env"}, "can't resolve the directory test/multiline/secrets"},
+	}
+
+	for _, tt := range tests {
+		if err := ResolveInteractive(tt.c, strings.NewReader("a\n"), ioutil.Discard); err == nil || err.Error() != tt.expected {
+			t.Fatalf("expected=%s actual=%v", tt.expected, err)
+		}
+	}
+
+	before, err := ioutil.ReadFile("test/a.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ioutil.ReadFile(working)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(before, after) {
+		t.Fatal("expected the working file to be unchanged")
+	}
+}
@@ -0,0 +1,426 @@
+package cfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Result holds the outcome of a scan between a working and master file
+type Result struct {
+	// WorkingPath and MasterPath are the paths as given. WorkingRealPath and
+	// MasterRealPath are the local files actually read, with symlinks resolved
+	WorkingPath     string `json:"workingPath"`
+	WorkingRealPath string `json:"workingRealPath,omitempty"`
+	MasterPath      string `json:"masterPath"`
+	MasterRealPath  string `json:"masterRealPath,omitempty"`
+
+	// Missing holds keys that exist in the master file and are missing in the
+	// working file. nested keys are dotted paths, e.g. database.replica.host
+	Missing []string `json:"missing"`
+
+	// MissingWithDefault and MissingRequired split the missing keys by their
+	// master value. a key is required when its master value is empty, null or
+	// one of Config.Placeholders, so the working file must provide one
+	MissingWithDefault []string `json:"missingWithDefault,omitempty"`
+	MissingRequired    []string `json:"missingRequired,omitempty"`
+
+	// MissingSources maps each missing key to the master file that defined
+	// it. only populated when Config.MasterPaths is set
+	MissingSources map[string]string `json:"missingSources,omitempty"`
+
+	// Extra holds keys that exist in the working file and are missing in the
+	// master file
+	Extra []string `json:"extra"`
+
+	// ExtraSources maps each extra key to the file that defined it. only
+	// populated by Invert, from the MissingSources of the inverted Result
+	ExtraSources map[string]string `json:"extraSources,omitempty"`
+
+	// Forbidden holds keys in the working file that match Config.ForbiddenKeys
+	Forbidden []string `json:"forbidden,omitempty"`
+
+	// DanglingRefs holds references within working values to keys that
+	// aren't defined, as "KEY -> REF". only populated when Config.ExpandEnv
+	// is set
+	DanglingRefs []string `json:"danglingRefs,omitempty"`
+
+	// OrderChanged holds keys present in both files but at different
+	// positions. only populated when Config.CheckOrder, or Config.JSONOrdered
+	// for json files, is set
+	OrderChanged []string `json:"orderChanged,omitempty"`
+
+	// UsingDefault holds keys missing from the working file that fall back to
+	// Config.DefaultsPath. only populated when it is set
+	UsingDefault []string `json:"usingDefault,omitempty"`
+
+	// ShapeChanged holds the keys whose master value is a scalar and working
+	// value an array, or the reverse, as "KEY: was scalar, now array". these
+	// keys are also different
+	ShapeChanged []string `json:"shapeChanged,omitempty"`
+
+	// OptionalSections holds the paths of Config.OptionalSections that are
+	// absent from the working file as a whole, which aren't reported as
+	// missing
+	OptionalSections []string `json:"optionalSections,omitempty"`
+
+	// TypeMismatches holds keys whose values are of different json types in
+	// each file. only populated when Config.CompareTypes is set
+	TypeMismatches []TypeMismatch `json:"typeMismatches,omitempty"`
+
+	// TypeWarnings holds keys whose values compared equal only once coerced,
+	// a string against a number, bool or null, e.g. an env PORT=8080 against
+	// a json 8080. only populated when Config.StrictTypes is set
+	TypeWarnings []string `json:"typeWarnings,omitempty"`
+
+	// CommentChanged holds keys in both files whose leading comments differ,
+	// with the comments as the master and working values. only populated
+	// when Config.CompareComments is set
+	CommentChanged []DiffEntry `json:"commentChanged,omitempty"`
+
+	// Malformed holds env lines that aren't a valid key value pair, e.g. a key
+	// without a "=" or a value without a key, as "path:line: text". the text
+	// after what would be the key of the line is redacted
+	Malformed []string `json:"malformed,omitempty"`
+
+	// Different holds keys that exist in both files with different values
+	Different []DiffEntry `json:"different"`
+
+	// ElementChanges holds the added and removed elements of the different
+	// arrays of scalars compared as an ArraySet, or the changed element
+	// counts of those compared as an ArrayMultiset, keyed by their path
+	ElementChanges map[string]ElementDiff `json:"elementChanges,omitempty"`
+
+	// Error holds why the working file couldn't be scanned, when it is one of
+	// several files scanned together, e.g. by ScanWorkings. no other fields
+	// but the paths are populated
+	Error string `json:"error,omitempty"`
+
+	// ScannedAt is when the scan started and Duration how long it took,
+	// including reading both files
+	ScannedAt time.Time     `json:"scannedAt"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// TypeMismatch holds the json types of the master and working values of a
+// key, e.g. number and string
+type TypeMismatch struct {
+	Key     string `json:"key"`
+	Master  string `json:"master"`
+	Working string `json:"working"`
+}
+
+// String returns the mismatch as "KEY: master -> working"
+func (t TypeMismatch) String() string {
+	return fmt.Sprintf("%s: %s -> %s", t.Key, t.Master, t.Working)
+}
+
+// ScanErrors maps the files of a multi-file scan that couldn't be scanned, or
+// in Config.StrictMode are missing keys, to why. it is returned along with
+// the results of the files that could
+type ScanErrors map[string]error
+
+// Error lists each file and its error, ordered by file
+func (e ScanErrors) Error() string {
+	names := []string{}
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := []string{}
+	for _, name := range names {
+		errs = append(errs, fmt.Sprintf("%s: %s", name, e[name]))
+	}
+
+	return fmt.Sprintf("could not scan %d files. %s", len(e), strings.Join(errs, "; "))
+}
+
+// err returns the ScanErrors as an error, or nil if there are none
+func (e ScanErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// DiffEntry holds the master and working values of a key that differs
+// between the two files
+type DiffEntry struct {
+	Key     string `json:"key"`
+	Master  string `json:"master"`
+	Working string `json:"working"`
+
+	// SourceFile is the master file that defined the key. only populated
+	// when Config.MasterPaths is set
+	SourceFile string `json:"sourceFile,omitempty"`
+}
+
+// ElementDiff holds the elements the working file gained and lost for a
+// different array of scalars compared as an ArraySet, or the changed counts
+// of its elements when compared as an ArrayMultiset
+type ElementDiff struct {
+	Added   []string       `json:"added,omitempty"`
+	Removed []string       `json:"removed,omitempty"`
+	Counts  []ElementCount `json:"counts,omitempty"`
+}
+
+// ElementCount holds how many times an element occurs in the master and
+// working arrays
+type ElementCount struct {
+	Element string `json:"element"`
+	Master  int    `json:"master"`
+	Working int    `json:"working"`
+}
+
+// String returns the count as "element: master -> working"
+func (c ElementCount) String() string {
+	return fmt.Sprintf("%s: %d -> %d", c.Element, c.Master, c.Working)
+}
+
+// String returns the entry as a KEY=value pair using the working value
+func (d DiffEntry) String() string {
+	return fmt.Sprintf("%s=%s", d.Key, d.Working)
+}
+
+// Diff renders the Result as a multi-line, categorized text report with one
+// key per line, e.g. for sending in a notification
+func (r *Result) Diff() string {
+	var b strings.Builder
+
+	section := func(name string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+
+		fmt.Fprintf(&b, "%s (%d):\n", name, len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+
+	different := []string{}
+	for _, d := range r.Different {
+		e, ok := r.ElementChanges[d.Key]
+
+		switch {
+		case ok && e.Counts != nil:
+			counts := []string{}
+			for _, c := range e.Counts {
+				counts = append(counts, c.String())
+			}
+			different = append(different, fmt.Sprintf("%s: %s", d.Key, strings.Join(counts, ", ")))
+		case ok:
+			different = append(different, fmt.Sprintf("%s: added %v, removed %v", d.Key, e.Added, e.Removed))
+		default:
+			different = append(different, fmt.Sprintf("%s: %s -> %s", d.Key, d.Master, d.Working))
+		}
+	}
+
+	mismatches := []string{}
+	for _, m := range r.TypeMismatches {
+		mismatches = append(mismatches, m.String())
+	}
+
+	comments := []string{}
+	for _, d := range r.CommentChanged {
+		comments = append(comments, fmt.Sprintf("%s: %q -> %q", d.Key, d.Master, d.Working))
+	}
+
+	section("malformed", r.Malformed)
+	section("forbidden", r.Forbidden)
+	section("missing", r.Missing)
+	section("using default", r.UsingDefault)
+	section("extra", r.Extra)
+	section("different", different)
+	section("scalar and array changes", r.ShapeChanged)
+	section("type mismatches", mismatches)
+	section("order changed", r.OrderChanged)
+	section("dangling references", r.DanglingRefs)
+	section("type warnings", r.TypeWarnings)
+	section("comment changed", comments)
+
+	if b.Len() == 0 {
+		return fmt.Sprintf("%s is in sync with %s\n", r.WorkingPath, r.MasterPath)
+	}
+
+	return fmt.Sprintf("%s compared to %s\n", r.WorkingPath, r.MasterPath) + b.String()
+}
+
+// String returns a one line summary of the Result, e.g. "cfg: 2 missing"
+func (r *Result) String() string {
+	return r.compact()
+}
+
+// copySources returns a copy of a map of keys to the files defining them
+func copySources(sources map[string]string) map[string]string {
+	if sources == nil {
+		return nil
+	}
+
+	out := make(map[string]string, len(sources))
+	for key, source := range sources {
+		out[key] = source
+	}
+
+	return out
+}
+
+// Invert returns a copy of the Result with the roles of the master and
+// working files swapped, along with their paths. missing keys become extra,
+// extra keys become missing and every difference has its master and working
+// values exchanged. forbidden
+// keys only apply to the original working file and are not carried over
+func (r *Result) Invert() *Result {
+	inverted := &Result{
+		WorkingPath:     r.MasterPath,
+		WorkingRealPath: r.MasterRealPath,
+		MasterPath:      r.WorkingPath,
+		MasterRealPath:  r.WorkingRealPath,
+		Missing:         append([]string(nil), r.Extra...),
+		Extra:           append([]string(nil), r.Missing...),
+		ScannedAt:       r.ScannedAt,
+		Duration:        r.Duration,
+	}
+
+	for _, d := range r.Different {
+		inverted.Different = append(inverted.Different, DiffEntry{
+			Key:        d.Key,
+			Master:     d.Working,
+			Working:    d.Master,
+			SourceFile: d.SourceFile,
+		})
+	}
+
+	// the keys a master defined are still keyed to it once they're extra
+	inverted.MissingSources, inverted.ExtraSources = copySources(r.ExtraSources), copySources(r.MissingSources)
+
+	for key, e := range r.ElementChanges {
+		if inverted.ElementChanges == nil {
+			inverted.ElementChanges = map[string]ElementDiff{}
+		}
+		counts := []ElementCount(nil)
+		for _, c := range e.Counts {
+			counts = append(counts, ElementCount{Element: c.Element, Master: c.Working, Working: c.Master})
+		}
+		inverted.ElementChanges[key] = ElementDiff{Added: e.Removed, Removed: e.Added, Counts: counts}
+	}
+
+	// a value that was a scalar in the master is one in the working file
+	// once inverted
+	flip := strings.NewReplacer(": was scalar, now array", ": was array, now scalar",
+		": was array, now scalar", ": was scalar, now array")
+	for _, s := range r.ShapeChanged {
+		inverted.ShapeChanged = append(inverted.ShapeChanged, flip.Replace(s))
+	}
+
+	for _, m := range r.TypeMismatches {
+		inverted.TypeMismatches = append(inverted.TypeMismatches, TypeMismatch{
+			Key:     m.Key,
+			Master:  m.Working,
+			Working: m.Master,
+		})
+	}
+
+	for _, d := range r.CommentChanged {
+		inverted.CommentChanged = append(inverted.CommentChanged, DiffEntry{
+			Key:     d.Key,
+			Master:  d.Working,
+			Working: d.Master,
+		})
+	}
+
+	return inverted
+}
+
+// GroupByPrefix buckets the keys of a Result by their top level prefix, the
+// part of the key before the first "_". keys without a "_" are their own
+// prefix
+func (r *Result) GroupByPrefix() map[string]*Result {
+	groups := map[string]*Result{}
+
+	group := func(key string) *Result {
+		p := prefix(key)
+		if _, ok := groups[p]; !ok {
+			groups[p] = &Result{}
+		}
+		return groups[p]
+	}
+
+	for _, key := range r.Missing {
+		g := group(key)
+		g.Missing = append(g.Missing, key)
+	}
+
+	for _, key := range r.Extra {
+		g := group(key)
+		g.Extra = append(g.Extra, key)
+	}
+
+	for _, d := range r.Different {
+		g := group(d.Key)
+		g.Different = append(g.Different, d)
+	}
+
+	return groups
+}
+
+// PatternCounts holds how many keys matching a pattern fall into each
+// category of a Result
+type PatternCounts struct {
+	Missing   int `json:"missing"`
+	Extra     int `json:"extra"`
+	Different int `json:"different"`
+}
+
+// GroupByPattern counts the missing, extra and different keys of a Result
+// matching each of the given glob patterns, e.g. "feature.*". a key matching
+// several patterns is counted under each
+func (r *Result) GroupByPattern(patterns []string) map[string]PatternCounts {
+	counts := map[string]PatternCounts{}
+
+	for _, pattern := range patterns {
+		c := PatternCounts{}
+
+		for _, key := range r.Missing {
+			if match([]string{pattern}, key) {
+				c.Missing++
+			}
+		}
+
+		for _, key := range r.Extra {
+			if match([]string{pattern}, key) {
+				c.Extra++
+			}
+		}
+
+		for _, d := range r.Different {
+			if match([]string{pattern}, d.Key) {
+				c.Different++
+			}
+		}
+
+		counts[pattern] = c
+	}
+
+	return counts
+}
+
+// prefixes returns the sorted, unique prefixes of all keys within a Result
+func (r *Result) prefixes() []string {
+	prefixes := []string{}
+	for p := range r.GroupByPrefix() {
+		prefixes = append(prefixes, p)
+	}
+
+	sort.Strings(prefixes)
+
+	return prefixes
+}
+
+// prefix returns the part of a key before the first "_"
+func prefix(key string) string {
+	return strings.SplitN(key, "_", 2)[0]
+}
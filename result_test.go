@@ -0,0 +1,196 @@
+package cfg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGroupByPrefix(t *testing.T) {
+	r := &Result{
+		Missing:   []string{"DB_HOST", "DB_PORT", "CACHE_TTL", "DEBUG"},
+		Different: []DiffEntry{{Key: "DB_NAME", Working: "app"}, {Key: "CACHE_SIZE_MB", Working: "64"}},
+	}
+
+	groups := r.GroupByPrefix()
+
+	tests := []struct {
+		prefix    string
+		missing   int
+		different int
+	}{
+		{"DB", 2, 1},
+		{"CACHE", 1, 1},
+		{"DEBUG", 1, 0},
+	}
+
+	if len(groups) != len(tests) {
+		t.Fatalf("expected=%d actual=%d", len(tests), len(groups))
+	}
+
+	for _, tt := range tests {
+		g := groups[tt.prefix]
+		if len(g.Missing) != tt.missing || len(g.Different) != tt.different {
+			t.Fatalf("prefix=%s expected=%d/%d actual=%d/%d", tt.prefix,
+				tt.missing, tt.different, len(g.Missing), len(g.Different))
+		}
+	}
+}
+
+func TestGroupByPattern(t *testing.T) {
+	r := &Result{
+		Missing:   []string{"feature.search", "feature.beta.chat", "db.host"},
+		Extra:     []string{"feature.legacy"},
+		Different: []DiffEntry{{Key: "db.port"}, {Key: "db.name"}, {Key: "cache.ttl"}},
+	}
+
+	counts := r.GroupByPattern([]string{"feature.*", "db.*", "queue.*"})
+
+	tests := []struct {
+		pattern  string
+		expected PatternCounts
+	}{
+		{"feature.*", PatternCounts{Missing: 2, Extra: 1}},
+		{"db.*", PatternCounts{Missing: 1, Different: 2}},
+		{"queue.*", PatternCounts{}},
+	}
+
+	if len(counts) != len(tests) {
+		t.Fatalf("expected=%d actual=%d", len(tests), len(counts))
+	}
+
+	for _, tt := range tests {
+		if counts[tt.pattern] != tt.expected {
+			t.Fatalf("pattern=%s expected=%+v actual=%+v", tt.pattern, tt.expected, counts[tt.pattern])
+		}
+	}
+}
+
+func TestInvert(t *testing.T) {
+	r := &Result{
+		WorkingPath:     ".env",
+		WorkingRealPath: "/app/.env",
+		MasterPath:      ".env.example",
+		ScannedAt:       time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Duration:        time.Second,
+		Missing:         []string{"FOOD", "LANG"},
+		Extra:           []string{"SPORT"},
+		Different:       []DiffEntry{{Key: "FRUIT", Master: "Mango", Working: "Guava"}, {Key: "TAGS", Master: `["a"]`, Working: `["b"]`}},
+		ElementChanges: map[string]ElementDiff{
+			"TAGS": {Added: []string{"b"}, Removed: []string{"a"}},
+		},
+		ShapeChanged:   []string{"HOSTS: was scalar, now array", "PORTS: was array, now scalar"},
+		MissingSources: map[string]string{"FOOD": "base.env", "LANG": "prod.env"},
+	}
+
+	r.Different[0].SourceFile = "prod.env"
+
+	inverted := r.Invert()
+
+	if expected := []string{"HOSTS: was array, now scalar", "PORTS: was scalar, now array"}; !reflect.DeepEqual(expected, inverted.ShapeChanged) {
+		t.Fatalf("expected=%+v actual=%+v", expected, inverted.ShapeChanged)
+	}
+
+	if inverted.WorkingPath != ".env.example" || inverted.MasterPath != ".env" || inverted.MasterRealPath != "/app/.env" {
+		t.Fatalf("expected the paths swapped actual=%+v", inverted)
+	}
+
+	if !inverted.ScannedAt.Equal(r.ScannedAt) || inverted.Duration != r.Duration {
+		t.Fatalf("expected the scan time kept actual=%+v", inverted)
+	}
+
+	if !strings.HasPrefix(inverted.Diff(), ".env.example compared to .env\n") {
+		t.Fatalf("expected the swapped paths in the diff actual=%s", inverted.Diff())
+	}
+
+	if len(inverted.Missing) != 1 || inverted.Missing[0] != "SPORT" {
+		t.Fatalf("expected=%+v actual=%+v", r.Extra, inverted.Missing)
+	}
+
+	if len(inverted.Extra) != 2 || inverted.Extra[0] != "FOOD" {
+		t.Fatalf("expected=%+v actual=%+v", r.Missing, inverted.Extra)
+	}
+
+	d := inverted.Different[0]
+	if d.Master != "Guava" || d.Working != "Mango" {
+		t.Fatalf("expected master=Guava working=Mango actual=%+v", d)
+	}
+
+	if e := inverted.ElementChanges["TAGS"]; len(e.Added) != 1 || e.Added[0] != "a" {
+		t.Fatalf("expected added=[a] actual=%+v", e)
+	}
+
+	if inverted.Different[0].SourceFile != "prod.env" || inverted.ExtraSources["LANG"] != "prod.env" || inverted.MissingSources != nil {
+		t.Fatalf("expected the sources kept actual=%+v", inverted)
+	}
+
+	// inverting twice restores the original roles
+	if again := inverted.Invert(); again.Different[0] != r.Different[0] {
+		t.Fatalf("expected=%+v actual=%+v", r.Different[0], again.Different[0])
+	}
+
+	if again := inverted.Invert(); !reflect.DeepEqual(again.MissingSources, r.MissingSources) {
+		t.Fatalf("expected=%+v actual=%+v", r.MissingSources, again.MissingSources)
+	}
+}
+
+func TestScanInverted(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/a.env",
+		MasterPath:  "test/b.env",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.WorkingPath, c.MasterPath = c.MasterPath, c.WorkingPath
+
+	reversed, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inverted := result.Invert()
+
+	if len(inverted.Extra) != len(reversed.Extra) || len(inverted.Missing) != len(reversed.Missing) {
+		t.Fatalf("expected=%+v actual=%+v", reversed, inverted)
+	}
+}
+
+func TestResultDiff(t *testing.T) {
+	r := &Result{
+		WorkingPath: "test/c.env",
+		MasterPath:  "test/d.env",
+		Missing:     []string{"FRUIT", "ANIMAL"},
+		Different:   []DiffEntry{{Key: "SPORT", Master: "Rugby", Working: "Football"}},
+	}
+
+	expected := `test/c.env compared to test/d.env
+missing (2):
+  FRUIT
+  ANIMAL
+different (1):
+  SPORT: Rugby -> Football
+`
+
+	if actual := r.Diff(); actual != expected {
+		t.Fatalf("expected=%s actual=%s", expected, actual)
+	}
+
+	r.CommentChanged = []DiffEntry{{Key: "SPORT", Master: "the sport", Working: ""}}
+	if actual := r.Diff(); !strings.HasSuffix(actual, "comment changed (1):\n  SPORT: \"the sport\" -> \"\"\n") {
+		t.Fatalf("expected the comment changes last actual=%s", actual)
+	}
+	r.CommentChanged = nil
+
+	if actual := (&Result{WorkingPath: "a", MasterPath: "b"}).Diff(); actual != "a is in sync with b\n" {
+		t.Fatalf("expected=%s actual=%s", "a is in sync with b", actual)
+	}
+
+	if actual := r.String(); actual != "cfg: 2 missing, 1 diff" {
+		t.Fatalf("expected=%s actual=%s", "cfg: 2 missing, 1 diff", actual)
+	}
+}
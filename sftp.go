@@ -0,0 +1,183 @@
+package cfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultSSHTimeout is used when no Config.SSHTimeout is provided
+const defaultSSHTimeout = 30 * time.Second
+
+// sftpFetcher holds data for reading a remote master file over sftp with a
+// native ssh connection
+type sftpFetcher struct {
+	addr   string
+	config ssh.ClientConfig
+
+	// signer authenticates the user, the ssh-agent is used when nil
+	signer ssh.Signer
+}
+
+// newSftpFetcher returns a new sftpFetcher for Config.SSHHost, loading its
+// key and known hosts so that a bad config fails before anything is read
+func newSftpFetcher(c Config) (*sftpFetcher, error) {
+	port := c.SSHPort
+	if port == 0 {
+		port = 22
+	}
+
+	name := c.SSHUser
+	if name == "" {
+		u, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the ssh user. %s", err)
+		}
+		name = u.Username
+	}
+
+	timeout := c.SSHTimeout
+	if timeout <= 0 {
+		timeout = defaultSSHTimeout
+	}
+
+	knownHosts := c.SSHKnownHosts
+	if knownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("could not find known_hosts. %s", err)
+		}
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	hostKey, err := knownhosts.New(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("could not load known hosts %s. %s", knownHosts, err)
+	}
+
+	f := &sftpFetcher{
+		addr: net.JoinHostPort(c.SSHHost, strconv.Itoa(port)),
+		config: ssh.ClientConfig{
+			User:            name,
+			HostKeyCallback: hostKey,
+			Timeout:         timeout,
+		},
+	}
+
+	if c.SSHKey != "" {
+		if f.signer, err = sshSigner(c.SSHKey, c.SSHKeyPassphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// sshSigner parses a private key, a file path or PEM encoded
+func sshSigner(key, passphrase string) (ssh.Signer, error) {
+	b, err := pemOrFile(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(b, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(b)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not load ssh key. %s", err)
+	}
+
+	return signer, nil
+}
+
+// get connects to the host and reads the file at path over sftp, closing the
+// connection if ctx is done first
+func (f *sftpFetcher) get(ctx context.Context, path string) ([]byte, error) {
+	config := f.config
+
+	if f.signer != nil {
+		config.Auth = []ssh.AuthMethod{ssh.PublicKeys(f.signer)}
+	} else {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, errors.New("no SSHKey given and no ssh-agent is running")
+		}
+
+		conn, err := (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to ssh-agent. %s", err)
+		}
+		defer conn.Close()
+
+		config.Auth = []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}
+	}
+
+	conn, err := (&net.Dialer{Timeout: config.Timeout}).DialContext(ctx, "tcp", f.addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s. %s", f.addr, ctxErr(ctx, err))
+	}
+
+	// closing the connection interrupts the handshake or transfer
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, f.addr, &config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not connect to %s. %s", f.addr, ctxErr(ctx, err))
+	}
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+
+	session, err := sftp.NewClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("could not start sftp on %s. %s", f.addr, err)
+	}
+	defer session.Close()
+
+	file, err := session.Open(path)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer file.Close()
+
+	b, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+
+	return b, nil
+}
+
+// ctxErr returns the error of ctx if it's done, as it caused err
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
@@ -0,0 +1,195 @@
+package cfg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshServer starts a sftp server on a random local port accepting the
+// client key, returning its port, a known_hosts file holding its key and the
+// client's PEM encoded private key
+func sshServer(t *testing.T) (port int, knownHostsPath, clientKey string) {
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorized, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSftp(conn, config)
+		}
+	}()
+
+	port = listener.Addr().(*net.TCPAddr).Port
+
+	knownHostsPath = filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize("127.0.0.1:" + strconv.Itoa(port))}, hostSigner.PublicKey())
+	if err := ioutil.WriteFile(knownHostsPath, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return port, knownHostsPath, string(pem.EncodeToMemory(block))
+}
+
+// serveSftp serves the sftp subsystem over one ssh connection
+func serveSftp(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for ch := range chans {
+		channel, requests, err := ch.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					server, err := sftp.NewServer(channel)
+					if err != nil {
+						return
+					}
+					server.Serve()
+					channel.Close()
+				}
+			}
+		}()
+	}
+}
+
+func TestScanSftp(t *testing.T) {
+	port, knownHosts, key := sshServer(t)
+
+	master, err := filepath.Abs("test/b.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath:   "test/a.env",
+		MasterPath:    master,
+		SSHHost:       "127.0.0.1",
+		SSHPort:       port,
+		SSHUser:       "cfg",
+		SSHKey:        key,
+		SSHKnownHosts: knownHosts,
+	}
+
+	missing, err := ScanEnv(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"FOOD", "LANG", "DRINK"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+}
+
+func TestScanSftpContext(t *testing.T) {
+	port, knownHosts, key := sshServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ScanEnvContext(ctx, Config{
+		WorkingPath:   "test/a.env",
+		MasterPath:    "test/b.env",
+		SSHHost:       "127.0.0.1",
+		SSHPort:       port,
+		SSHUser:       "cfg",
+		SSHKey:        key,
+		SSHKnownHosts: knownHosts,
+	})
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("expected a cancelled error actual=%v", err)
+	}
+}
+
+func TestScanSftpErrors(t *testing.T) {
+	port, knownHosts, key := sshServer(t)
+
+	// a second server's key is unknown
+	_, otherHosts, otherKey := sshServer(t)
+
+	tests := []struct {
+		c   Config
+		err string
+	}{
+		{Config{SSHKey: key, SSHKnownHosts: otherHosts}, "key is unknown"},
+		{Config{SSHKey: otherKey, SSHKnownHosts: knownHosts}, "unable to authenticate"},
+		{Config{SSHKey: "not a key", SSHKnownHosts: knownHosts}, "could not open not a key"},
+		{Config{SSHKey: key, SSHKnownHosts: "test/missing_known_hosts"}, "could not load known hosts"},
+		{Config{SSHKey: key, SSHKnownHosts: knownHosts, MasterPath: "/missing/.env"}, "could not open /missing/.env"},
+	}
+
+	for _, tt := range tests {
+		tt.c.WorkingPath = "test/a.env"
+		if tt.c.MasterPath == "" {
+			tt.c.MasterPath = "test/b.env"
+		}
+		tt.c.SSHHost, tt.c.SSHPort, tt.c.SSHUser = "127.0.0.1", port, "cfg"
+
+		_, err := ScanEnv(tt.c)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("expected=%s actual=%v", tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cfg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SaveSnapshot stores the current contents of the working file under name,
+// so it can later be compared against with CompareSnapshot. snapshots are
+// kept in Config.SnapshotDir. the raw file is stored, before any DecryptFunc
+// is applied, so secrets aren't written to disk in plain text. nothing is
+// written if Config.DryRun is set
+func SaveSnapshot(c Config, name string) error {
+	path, err := c.snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	a, err := initAnalyzer(c)
+	if err != nil {
+		return err
+	}
+
+	b, err := a.readRawWorking(c.WorkingPath)
+	if err != nil {
+		return err
+	}
+
+	if c.dryRun("would save snapshot %s of %d bytes to %s", name, len(b), path) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("could not create %s. %s", filepath.Dir(path), err)
+	}
+
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		return fmt.Errorf("could not write %s. %s", path, err)
+	}
+
+	a.log.Info("saved snapshot", "name", name, "path", path, "bytes", len(b))
+
+	return nil
+}
+
+// CompareSnapshot compares the current working file against the snapshot
+// stored under name, treating the snapshot as the master. keys removed since
+// the snapshot are reported as missing, keys added as extra and changed
+// values as different
+func CompareSnapshot(c Config, name string) (*Result, error) {
+	path, err := c.snapshotPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.MasterPath = path
+
+	a, err := initAnalyzer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.readWorking(c.WorkingPath); err != nil {
+		return nil, err
+	}
+
+	if a.master, err = ioutil.ReadFile(path); err != nil {
+		return nil, fmt.Errorf("could not open snapshot %s. %s", name, err)
+	}
+
+	if a.master, err = a.decode(a.master, path); err != nil {
+		return nil, err
+	}
+
+	return a.scan(c.format())
+}
+
+// snapshotPath returns where the snapshot of the given name is stored
+func (c Config) snapshotPath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	dir := c.SnapshotDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("could not find a snapshot directory. %s", err)
+		}
+		dir = filepath.Join(cache, "cfganalyze", "snapshots")
+	}
+
+	return filepath.Join(dir, name), nil
+}
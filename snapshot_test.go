@@ -0,0 +1,132 @@
+package cfg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	working := filepath.Join(dir, ".env")
+
+	if err := ioutil.WriteFile(working, []byte("FRUIT=Mango\nANIMAL=Koala\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: working,
+		SnapshotDir: filepath.Join(dir, "snapshots"),
+	}
+
+	if err := SaveSnapshot(c, "before"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(working, []byte("FRUIT=Guava\nSPORT=Rugby\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := CompareSnapshot(c, "before")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "ANIMAL" {
+		t.Fatalf("expected=[ANIMAL] actual=%+v", result.Missing)
+	}
+
+	if len(result.Extra) != 1 || result.Extra[0] != "SPORT" {
+		t.Fatalf("expected=[SPORT] actual=%+v", result.Extra)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Master != "Mango" {
+		t.Fatalf("expected FRUIT to differ from Mango actual=%+v", result.Different)
+	}
+}
+
+func TestSnapshotToml(t *testing.T) {
+	dir := t.TempDir()
+	working := filepath.Join(dir, "config.toml")
+
+	if err := ioutil.WriteFile(working, []byte("[db]\nhost = \"localhost\"\nport = 5432\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		WorkingPath: working,
+		SnapshotDir: filepath.Join(dir, "snapshots"),
+	}
+
+	if err := SaveSnapshot(c, "before"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file is stored as written, not as the json it's compared as
+	b, err := ioutil.ReadFile(filepath.Join(c.SnapshotDir, "before"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(b), "[db]") {
+		t.Fatalf("expected the raw file actual=%s", b)
+	}
+
+	if err := ioutil.WriteFile(working, []byte("[db]\nhost = \"db.internal\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := CompareSnapshot(c, "before")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "db.port" {
+		t.Fatalf("expected=[db.port] actual=%+v", result.Missing)
+	}
+
+	if len(result.Different) != 1 || result.Different[0].Master != "localhost" {
+		t.Fatalf("expected db.host to differ from localhost actual=%+v", result.Different)
+	}
+}
+
+func TestSnapshotInvalidName(t *testing.T) {
+	c := Config{WorkingPath: "test/a.env", SnapshotDir: t.TempDir()}
+
+	for _, name := range []string{"", "..", "a/b"} {
+		if err := SaveSnapshot(c, name); err == nil {
+			t.Fatalf("expected an error for snapshot name %q", name)
+		}
+	}
+
+	if _, err := CompareSnapshot(c, "never-saved"); err == nil {
+		t.Fatal("expected an error for a snapshot that doesn't exist")
+	}
+}
+
+func TestSnapshotDryRun(t *testing.T) {
+	var out bytes.Buffer
+
+	dir := t.TempDir()
+	c := Config{
+		WorkingPath:  "test/a.env",
+		SnapshotDir:  filepath.Join(dir, "snapshots"),
+		DryRun:       true,
+		DryRunOutput: &out,
+	}
+
+	if err := SaveSnapshot(c, "before"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(c.SnapshotDir); !os.IsNotExist(err) {
+		t.Fatalf("expected no snapshot to be written actual=%v", err)
+	}
+
+	if !strings.HasPrefix(out.String(), "dry run: would save snapshot before") {
+		t.Fatalf("expected the snapshot to be printed actual=%s", out.String())
+	}
+}
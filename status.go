@@ -0,0 +1,47 @@
+package cfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompactStatus returns a one line summary of the scan suitable for shell
+// prompts or status bars, e.g. "cfg: 2 missing, 1 diff" or "cfg: ok". it
+// never panics, returning "cfg: error" alongside the error on failure
+func CompactStatus(c Config) (status string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			status, err = "cfg: error", fmt.Errorf("scan panicked: %v", r)
+		}
+	}()
+
+	result, err := Scan(c)
+	if err != nil {
+		return "cfg: error", err
+	}
+
+	return result.compact(), nil
+}
+
+// compact returns a one line summary of the Result
+func (r *Result) compact() string {
+	parts := []string{}
+
+	if len(r.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("%d missing", len(r.Missing)))
+	}
+
+	if len(r.Different) > 0 {
+		parts = append(parts, fmt.Sprintf("%d diff", len(r.Different)))
+	}
+
+	if len(r.Forbidden) > 0 {
+		parts = append(parts, fmt.Sprintf("%d forbidden", len(r.Forbidden)))
+	}
+
+	if len(parts) == 0 {
+		return "cfg: ok"
+	}
+
+	return "cfg: " + strings.Join(parts, ", ")
+}
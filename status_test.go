@@ -0,0 +1,23 @@
+package cfg
+
+import "testing"
+
+func TestCompactStatus(t *testing.T) {
+	tests := []struct {
+		working  string
+		master   string
+		expected string
+	}{
+		{"test/a.env", "test/b.env", "cfg: 3 missing"},
+		{"test/c.env", "test/d.env", "cfg: 1 diff"},
+		{"test/a.env", "test/a.env", "cfg: ok"},
+		{"test/a.env", "test/missing.env", "cfg: error"},
+	}
+
+	for _, tt := range tests {
+		status, _ := CompactStatus(Config{WorkingPath: tt.working, MasterPath: tt.master, AllowSamePath: true})
+		if status != tt.expected {
+			t.Fatalf("expected=%s actual=%s", tt.expected, status)
+		}
+	}
+}
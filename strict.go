@@ -0,0 +1,35 @@
+package cfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrMissingKeys is returned, along with the outcome of the scan, by the Scan
+// and Print functions in Config.StrictMode when the working file is missing
+// more keys of the master than Config.MissingThreshold allows
+type ErrMissingKeys struct {
+	// Path is the working file
+	Path string
+
+	// Keys are the keys missing from the working file
+	Keys []string
+}
+
+// Error lists the missing keys
+func (e *ErrMissingKeys) Error() string {
+	return fmt.Sprintf("%s is missing %d keys. %s", e.Path, len(e.Keys), strings.Join(e.Keys, ", "))
+}
+
+// strict returns an ErrMissingKeys for a Result missing keys when
+// Config.StrictMode is set, or nil
+func (c Config) strict(r *Result) error {
+	if !c.StrictMode || len(r.Missing) <= c.MissingThreshold {
+		return nil
+	}
+
+	return &ErrMissingKeys{
+		Path: label(r.WorkingPath, r.WorkingRealPath),
+		Keys: append([]string{}, r.Missing...),
+	}
+}
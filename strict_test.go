@@ -0,0 +1,121 @@
+package cfg
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestStrictMode(t *testing.T) {
+	tests := []struct {
+		name string
+		scan func(Config) ([]string, error)
+		c    Config
+	}{
+		{"env", ScanEnv, Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"}},
+		{"json", ScanJson, Config{WorkingPath: "test/a.json", MasterPath: "test/b.json"}},
+		{"result", func(c Config) ([]string, error) {
+			r, err := Scan(c)
+			if r == nil {
+				return nil, err
+			}
+			return r.Missing, err
+		}, Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"}},
+		{"analyzer", func(c Config) ([]string, error) {
+			r, err := NewAnalyzer(c).Scan(c.WorkingPath)
+			if r == nil {
+				return nil, err
+			}
+			return r.Missing, err
+		}, Config{WorkingPath: "test/a.env", MasterPath: "test/b.env"}},
+	}
+
+	for _, tt := range tests {
+		missing, err := tt.scan(tt.c)
+		if err != nil {
+			t.Fatalf("%s: expected no error without StrictMode actual=%s", tt.name, err)
+		}
+
+		tt.c.StrictMode = true
+
+		actual, err := tt.scan(tt.c)
+
+		var e *ErrMissingKeys
+		if !errors.As(err, &e) {
+			t.Fatalf("%s: expected ErrMissingKeys actual=%v", tt.name, err)
+		}
+
+		// json keys are reported in map order
+		for _, keys := range [][]string{missing, actual, e.Keys} {
+			sort.Strings(keys)
+		}
+
+		if !reflect.DeepEqual(missing, actual) || !reflect.DeepEqual(missing, e.Keys) {
+			t.Fatalf("%s: expected=%v actual=%v keys=%v", tt.name, missing, actual, e.Keys)
+		}
+
+		if e.Path != tt.c.WorkingPath {
+			t.Fatalf("%s: expected=%s actual=%s", tt.name, tt.c.WorkingPath, e.Path)
+		}
+
+		// keys within the threshold aren't an error
+		tt.c.MissingThreshold = len(missing)
+		if _, err := tt.scan(tt.c); err != nil {
+			t.Fatalf("%s: expected no error within the threshold actual=%s", tt.name, err)
+		}
+	}
+}
+
+func TestStrictModeInSync(t *testing.T) {
+	if _, err := ScanEnv(Config{WorkingPath: "test/c.env", MasterPath: "test/d.env", StrictMode: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrictModePrint(t *testing.T) {
+	c := Config{WorkingPath: "test/a.env", MasterPath: "test/b.env", StrictMode: true}
+
+	for _, format := range []OutputFormat{OutputText, OutputJson} {
+		c.OutputFormat = format
+
+		var err error
+		out := captureStdout(t, func() { err = PrintEnv(c) })
+
+		var e *ErrMissingKeys
+		if !errors.As(err, &e) {
+			t.Fatalf("format=%s expected ErrMissingKeys actual=%v", format, err)
+		}
+
+		// the report is still printed
+		if !strings.Contains(out, "DRINK") {
+			t.Fatalf("format=%s expected a report actual=%s", format, out)
+		}
+	}
+}
+
+func TestStrictModeWorkings(t *testing.T) {
+	c := Config{MasterPath: "test/b.env", StrictMode: true, AllowSamePath: true}
+
+	results, err := ScanWorkings([]string{"test/a.env", "test/b.env"}, c)
+
+	var errs ScanErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ScanErrors actual=%v", err)
+	}
+
+	if len(errs) != 1 || len(results) != 2 {
+		t.Fatalf("expected one error and two results actual=%v %v", errs, results)
+	}
+
+	var e *ErrMissingKeys
+	if !errors.As(errs["test/a.env"], &e) {
+		t.Fatalf("expected ErrMissingKeys actual=%v", errs["test/a.env"])
+	}
+
+	c.FailFast = true
+	if _, err := ScanWorkings([]string{"test/a.env"}, c); !errors.As(err, &e) {
+		t.Fatalf("expected ErrMissingKeys actual=%v", err)
+	}
+}
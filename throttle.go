@@ -0,0 +1,38 @@
+package cfg
+
+import (
+	"sync"
+	"time"
+)
+
+// connections throttles new ssh connections across every scan in the
+// process, so concurrent scans of many hosts share a single rate
+var connections = &throttle{}
+
+// throttle spaces out events so that no more than a given number happen per
+// second
+type throttle struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the next event is allowed at the given rate. a rate of
+// zero or less is unlimited
+func (t *throttle) wait(perSecond float64) {
+	if perSecond <= 0 {
+		return
+	}
+
+	interval := time.Duration(float64(time.Second) / perSecond)
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(interval)
+	t.mu.Unlock()
+
+	time.Sleep(delay)
+}
@@ -0,0 +1,41 @@
+package cfg
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	th := &throttle{}
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			th.wait(50)
+		}()
+	}
+	wg.Wait()
+
+	// the first connection is immediate, the other four are spaced 20ms apart
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("expected at least 80ms actual=%s", elapsed)
+	}
+}
+
+func TestThrottleUnlimited(t *testing.T) {
+	th := &throttle{}
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		th.wait(0)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Fatalf("expected no delay actual=%s", elapsed)
+	}
+}
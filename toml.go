@@ -0,0 +1,206 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// newTomlAnalyzer returns a new jsonAnalyzer for two .toml files, which are
+// converted to json as they're read
+func newTomlAnalyzer(c Config) (*jsonAnalyzer, error) {
+	c.Format = FormatToml
+	return newJsonAnalyzer(c)
+}
+
+// ScanToml will scan two .toml configuration files returning a slice of keys,
+// as dotted paths, that exist in the master file and are missing in the
+// working file. each table of an array of tables is compared by its index,
+// e.g. products.0.name
+func ScanToml(c Config) ([]string, error) {
+	analyzer, err := newTomlAnalyzer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer.scan()
+
+	return analyzer.missing, analyzer.config.strict(analyzer.result())
+}
+
+// ScanTomlResult will scan two .toml configuration files returning a Result
+// holding every missing, extra and different key
+func ScanTomlResult(c Config) (*Result, error) {
+	c.Format = FormatToml
+	return Scan(c)
+}
+
+// PrintToml uses ScanToml to retrieve a slice of missing keys and will then
+// print out the difference / discrepencies between the master and working files
+func PrintToml(c Config) error {
+	c.Format = FormatToml
+	return PrintJson(c)
+}
+
+// tomlToJson converts a toml document to json, keeping the order of its keys.
+// an array of tables becomes an object keyed by each table's index, so that
+// the keys of every table are compared
+func tomlToJson(b []byte) ([]byte, error) {
+	m := map[string]interface{}{}
+
+	md, err := toml.Decode(string(b), &m)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys are ordered by where they first appear, tables of an array sharing
+	// the same path
+	order := map[string]int{}
+	for i, key := range md.Keys() {
+		if _, ok := order[tomlPath(key)]; !ok {
+			order[tomlPath(key)] = i
+		}
+	}
+
+	w := tomlWriter{order: order}
+	if err := w.write(m, nil); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// tomlWriter writes decoded toml values as json, ordering the keys of each
+// table by their position in the document
+type tomlWriter struct {
+	buf   bytes.Buffer
+	order map[string]int
+}
+
+// write writes a value, and each value within it, as json. path holds the
+// keys of the value, without array indexes
+func (w *tomlWriter) write(v interface{}, path toml.Key) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Slice(keys, func(i, j int) bool {
+			return w.order[tomlPath(tomlChild(path, keys[i]))] < w.order[tomlPath(tomlChild(path, keys[j]))]
+		})
+
+		w.buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+
+			key, _ := json.Marshal(k)
+			w.buf.Write(key)
+			w.buf.WriteByte(':')
+
+			if err := w.write(v[k], tomlChild(path, k)); err != nil {
+				return err
+			}
+		}
+		w.buf.WriteByte('}')
+
+	case []map[string]interface{}:
+		return w.tables(v, path)
+
+	case []interface{}:
+		// an inline array of tables is an array of tables too
+		tables := []map[string]interface{}{}
+		for _, item := range v {
+			if t, ok := item.(map[string]interface{}); ok {
+				tables = append(tables, t)
+			}
+		}
+
+		if len(v) > 0 && len(tables) == len(v) {
+			return w.tables(tables, path)
+		}
+
+		w.buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+
+			if err := w.write(item, path); err != nil {
+				return err
+			}
+		}
+		w.buf.WriteByte(']')
+
+	case float64:
+		// nan and inf have no json equivalent so are kept as text
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return w.write(strconv.FormatFloat(v, 'f', -1, 64), path)
+		}
+
+		w.buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+
+	case time.Time:
+		// local dates and times are decoded into zones of these names, and
+		// are written without the parts they lack
+		layout := map[string]string{
+			"datetime-local": "2006-01-02T15:04:05.999999999",
+			"date-local":     "2006-01-02",
+			"time-local":     "15:04:05.999999999",
+		}[v.Location().String()]
+
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+
+		return w.write(v.Format(layout), path)
+
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("could not convert %s. %s", path, err)
+		}
+		w.buf.Write(b)
+	}
+
+	return nil
+}
+
+// tables writes an array of tables as an object keyed by each table's index
+func (w *tomlWriter) tables(tables []map[string]interface{}, path toml.Key) error {
+	w.buf.WriteByte('{')
+	for i, t := range tables {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+
+		fmt.Fprintf(&w.buf, `"%d":`, i)
+
+		if err := w.write(t, path); err != nil {
+			return err
+		}
+	}
+	w.buf.WriteByte('}')
+
+	return nil
+}
+
+// tomlChild returns the path of a key within the table at path
+func tomlChild(path toml.Key, key string) toml.Key {
+	return append(path[:len(path):len(path)], key)
+}
+
+// tomlPath joins the keys of a path so that keys containing dots stay apart
+func tomlPath(key toml.Key) string {
+	return strings.Join(key, "\x00")
+}
@@ -0,0 +1,74 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScanToml(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/toml/working.toml",
+		MasterPath:  "test/toml/master.toml",
+	}
+
+	missing, err := ScanToml(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second server lacks a role
+	sort.Strings(missing)
+	if expected := []string{"database.[replica.host]", "database.pool.idle", "servers.1.role"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	different := []string{}
+	for _, d := range result.Different {
+		different = append(different, d.String())
+	}
+
+	if expected := []string{"database.host=db.internal", "servers.1.ip=10.0.0.3"}; !reflect.DeepEqual(different, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, different)
+	}
+}
+
+func TestTomlToJson(t *testing.T) {
+	tests := []struct {
+		toml     string
+		expected string
+	}{
+		{"", `{}`},
+		{"b = 1\na = 'two'\n[c]\nz = 1.5\ny = true", `{"b":1,"a":"two","c":{"z":1.5,"y":true}}`},
+		{"a = [1, 2]\nb = [{x = 1}, {x = 2}]", `{"a":[1,2],"b":{"0":{"x":1},"1":{"x":2}}}`},
+		// the tables of an array share the order of their keys
+		{"[[a]]\nx = 1\n[[a]]\ny = 2\nx = 3", `{"a":{"0":{"x":1},"1":{"x":3,"y":2}}}`},
+		{"a = nan\nb = 07:32:00\nc = 2024-05-01\nd = 2024-05-01T07:32:00+02:00", `{"a":"NaN","b":"07:32:00","c":"2024-05-01","d":"2024-05-01T07:32:00+02:00"}`},
+		{"a = 1\na = 2", ""},
+		{"a = ", ""},
+	}
+
+	for _, tt := range tests {
+		b, err := tomlToJson([]byte(tt.toml))
+
+		if tt.expected == "" {
+			if err == nil {
+				t.Fatalf("toml=%q expected an error actual=%s", tt.toml, b)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("toml=%q %s", tt.toml, err)
+		}
+
+		if string(b) != tt.expected {
+			t.Fatalf("toml=%q expected=%s actual=%s", tt.toml, tt.expected, b)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package cfg
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// runLogEntry is the summary of a scan appended to a run log, one json
+// object per line
+type runLogEntry struct {
+	ScannedAt   time.Time `json:"scannedAt"`
+	WorkingPath string    `json:"workingPath"`
+	MasterPath  string    `json:"masterPath"`
+	Missing     int       `json:"missing"`
+	Extra       int       `json:"extra"`
+	Different   int       `json:"different"`
+}
+
+// Trend holds the scans of a run log grouped by service, the working path
+// scanned
+type Trend struct {
+	Services map[string]*ServiceTrend `json:"services"`
+}
+
+// ServiceTrend holds the scans of a single service in the order they ran
+type ServiceTrend struct {
+	Points []TrendPoint `json:"points"`
+
+	// Change is the number of missing keys of the latest scan less that of
+	// the first. a negative change means drift is improving
+	Change int `json:"change"`
+}
+
+// TrendPoint holds the counts of a single scan
+type TrendPoint struct {
+	ScannedAt time.Time `json:"scannedAt"`
+	Missing   int       `json:"missing"`
+	Extra     int       `json:"extra"`
+	Different int       `json:"different"`
+}
+
+// AppendRunLog appends a summary of a Result to the run log at path,
+// creating it if needed, to later build a TrendReport from
+func AppendRunLog(path string, r *Result) error {
+	b, err := json.Marshal(runLogEntry{
+		ScannedAt:   r.ScannedAt,
+		WorkingPath: r.WorkingPath,
+		MasterPath:  r.MasterPath,
+		Missing:     len(r.Missing),
+		Extra:       len(r.Extra),
+		Different:   len(r.Different),
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open %s. %s", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("could not write %s. %s", path, err)
+	}
+
+	return f.Close()
+}
+
+// TrendReport reads the run log at path, grouping its scans by service in
+// the order they ran
+func TrendReport(path string) (*Trend, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s. %s", path, err)
+	}
+	defer f.Close()
+
+	trend := &Trend{Services: map[string]*ServiceTrend{}}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e runLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("could not parse %s at line %d. %s", path, line, err)
+		}
+
+		s, ok := trend.Services[e.WorkingPath]
+		if !ok {
+			s = &ServiceTrend{}
+			trend.Services[e.WorkingPath] = s
+		}
+
+		s.Points = append(s.Points, TrendPoint{
+			ScannedAt: e.ScannedAt,
+			Missing:   e.Missing,
+			Extra:     e.Extra,
+			Different: e.Different,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s. %s", path, err)
+	}
+
+	for _, s := range trend.Services {
+		sort.SliceStable(s.Points, func(i, k int) bool {
+			return s.Points[i].ScannedAt.Before(s.Points[k].ScannedAt)
+		})
+
+		s.Change = s.Points[len(s.Points)-1].Missing - s.Points[0].Missing
+	}
+
+	return trend, nil
+}
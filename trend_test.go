@@ -0,0 +1,54 @@
+package cfg
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrendReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.log")
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	runs := []*Result{
+		{WorkingPath: "api/.env", ScannedAt: start, Missing: []string{"A", "B", "C"}},
+		{WorkingPath: "web/.env", ScannedAt: start, Missing: []string{"A"}},
+		{WorkingPath: "api/.env", ScannedAt: start.Add(24 * time.Hour), Missing: []string{"A"}},
+		{WorkingPath: "web/.env", ScannedAt: start.Add(24 * time.Hour), Missing: []string{"A", "B"}},
+	}
+
+	for _, r := range runs {
+		if err := AppendRunLog(path, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trend, err := TrendReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		service string
+		points  int
+		change  int
+	}{
+		{"api/.env", 2, -2},
+		{"web/.env", 2, 1},
+	}
+
+	if len(trend.Services) != len(tests) {
+		t.Fatalf("expected=%d actual=%d", len(tests), len(trend.Services))
+	}
+
+	for _, tt := range tests {
+		s := trend.Services[tt.service]
+		if len(s.Points) != tt.points || s.Change != tt.change {
+			t.Fatalf("service=%s expected=%d/%d actual=%d/%d", tt.service, tt.points, tt.change, len(s.Points), s.Change)
+		}
+	}
+
+	if _, err := TrendReport(filepath.Join(t.TempDir(), "nope.log")); err == nil {
+		t.Fatal("expected an error for a missing run log")
+	}
+}
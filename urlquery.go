@@ -0,0 +1,80 @@
+package cfg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// loadQueryAnalyzer returns a new envAnalyzer loaded with the key value
+// pairs of the base analyzer's url-encoded query string files
+func loadQueryAnalyzer(base *analyzer) (*envAnalyzer, error) {
+	c := base.config
+	analyzer := envAnalyzer{analyzer: *base}
+
+	var err error
+	if analyzer.envWorking, err = parseQuery(base.working); err != nil {
+		return nil, fmt.Errorf("could not parse %s. %s", c.WorkingPath, err)
+	}
+
+	if analyzer.envMaster, err = parseQuery(base.master); err != nil {
+		return nil, fmt.Errorf("could not parse %s. %s", c.MasterPath, err)
+	}
+
+	if c.KeyTransform != nil {
+		analyzer.transformKeys(analyzer.envWorking)
+		analyzer.transformKeys(analyzer.envMaster)
+	}
+
+	analyzer.envWorking = base.ignoreKeys.env(analyzer.envWorking)
+	analyzer.envMaster = base.ignoreKeys.env(analyzer.envMaster)
+
+	if err := analyzer.readDefaults(FormatEnv); err != nil {
+		return nil, err
+	}
+
+	analyzer.log.Debug("parsed url query config", "working_keys", len(analyzer.envWorking),
+		"master_keys", len(analyzer.envMaster))
+
+	return &analyzer, nil
+}
+
+// parseQuery parses a url-encoded query string, a=1&b=2, into key value
+// pairs in the order their keys first appear. the values of a repeated key
+// are joined by ","
+func parseQuery(b []byte) ([]configEnv, error) {
+	config := []configEnv{}
+	index := map[string]int{}
+
+	query := strings.TrimPrefix(strings.TrimSpace(string(b)), "?")
+
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+
+		key, err := url.QueryUnescape(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		value := ""
+		if len(parts) == 2 {
+			if value, err = url.QueryUnescape(parts[1]); err != nil {
+				return nil, err
+			}
+		}
+
+		if i, ok := index[key]; ok {
+			config[i].Value += "," + value
+			continue
+		}
+
+		index[key] = len(config)
+		config = append(config, configEnv{Key: key, Value: value})
+	}
+
+	return config, nil
+}
@@ -0,0 +1,50 @@
+package cfg
+
+import "testing"
+
+func TestScanURLQuery(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/urlquery/working.query",
+		MasterPath:  "test/urlquery/master.query",
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "debug" {
+		t.Fatalf("expected=[debug] actual=%+v", result.Missing)
+	}
+
+	if len(result.Extra) != 1 || result.Extra[0] != "extra" {
+		t.Fatalf("expected=[extra] actual=%+v", result.Extra)
+	}
+
+	// region is equal once percent-decoded
+	if len(result.Different) != 1 || result.Different[0].Key != "hosts" || result.Different[0].Working != "a,c" {
+		t.Fatalf("expected hosts to differ actual=%+v", result.Different)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	env, err := parseQuery([]byte("?a=1&b=x%20y&a=2&c\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []configEnv{{Key: "a", Value: "1,2"}, {Key: "b", Value: "x y"}, {Key: "c", Value: ""}}
+	if len(env) != len(expected) {
+		t.Fatalf("expected=%+v actual=%+v", expected, env)
+	}
+
+	for i := range expected {
+		if env[i] != expected[i] {
+			t.Fatalf("expected=%+v actual=%+v", expected[i], env[i])
+		}
+	}
+
+	if _, err := parseQuery([]byte("a=%zz")); err == nil {
+		t.Fatal("expected an error for an invalid escape")
+	}
+}
@@ -0,0 +1,207 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ScanXml will scan two .xml configuration files returning a slice of keys,
+// as dotted element paths, that exist in the master file and are missing in
+// the working file. attributes are keyed by their name prefixed with @, e.g.
+// configuration.appender.@name, and repeated elements by their index unless
+// listed in Config.XmlLists
+func ScanXml(c Config) ([]string, error) {
+	c.Format = FormatXml
+	return ScanJson(c)
+}
+
+// ScanXmlResult will scan two .xml configuration files returning a Result
+// holding every missing, extra and different key
+func ScanXmlResult(c Config) (*Result, error) {
+	c.Format = FormatXml
+	return Scan(c)
+}
+
+// PrintXml uses ScanXml to retrieve a slice of missing keys and will then
+// print out the difference / discrepencies between the master and working files
+func PrintXml(c Config) error {
+	c.Format = FormatXml
+	return PrintJson(c)
+}
+
+// xmlNode is an element of an xml document
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// xmlToJson converts an xml document to json, keeping the order of its
+// elements. the root element is the top level key. an element holding only
+// text is a string, any other an object of its attributes, child elements and,
+// under #text, its text. repeated child elements are an object keyed by their
+// index, or an array if their path matches one of lists
+func xmlToJson(b []byte, lists []string) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+
+	var root *xmlNode
+	stack := []*xmlNode{}
+
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root != nil {
+				return nil, fmt.Errorf("line %d: a document has one root element", xmlLine(d))
+			} else {
+				root = n
+			}
+
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fmt.Errorf("line %d: text outside the root element", xmlLine(d))
+			}
+		}
+	}
+
+	// an empty document has no keys
+	if root == nil {
+		if len(bytes.TrimSpace(b)) > 0 {
+			return nil, errors.New("no root element")
+		}
+		return []byte("{}"), nil
+	}
+
+	w := xmlWriter{lists: lists}
+
+	w.buf.WriteByte('{')
+	w.key(root.name)
+	w.write(root, joinKey("", root.name, "."))
+	w.buf.WriteByte('}')
+
+	return w.buf.Bytes(), nil
+}
+
+// xmlLine returns the line the decoder has read up to
+func xmlLine(d *xml.Decoder) int {
+	line, _ := d.InputPos()
+	return line
+}
+
+// xmlWriter writes xml elements as json
+type xmlWriter struct {
+	buf   bytes.Buffer
+	lists []string
+}
+
+// write writes an element at path, and each element within it, as json
+func (w *xmlWriter) write(n *xmlNode, path string) {
+	text := strings.TrimSpace(n.text.String())
+
+	if len(n.attrs) == 0 && len(n.children) == 0 {
+		w.value(text)
+		return
+	}
+
+	w.buf.WriteByte('{')
+
+	first := true
+	next := func(key string) {
+		if !first {
+			w.buf.WriteByte(',')
+		}
+		first = false
+		w.key(key)
+	}
+
+	for _, attr := range n.attrs {
+		next("@" + attr.Name.Local)
+		w.value(attr.Value)
+	}
+
+	// children are grouped by name in the order each name first appears
+	names, groups := []string{}, map[string][]*xmlNode{}
+	for _, child := range n.children {
+		if _, ok := groups[child.name]; !ok {
+			names = append(names, child.name)
+		}
+		groups[child.name] = append(groups[child.name], child)
+	}
+
+	for _, name := range names {
+		next(name)
+
+		group, childPath := groups[name], joinKey(path+".", name, ".")
+
+		switch {
+		case matchPath(w.lists, childPath, "."):
+			w.buf.WriteByte('[')
+			for i, child := range group {
+				if i > 0 {
+					w.buf.WriteByte(',')
+				}
+				w.write(child, childPath)
+			}
+			w.buf.WriteByte(']')
+
+		case len(group) > 1:
+			w.buf.WriteByte('{')
+			for i, child := range group {
+				if i > 0 {
+					w.buf.WriteByte(',')
+				}
+				w.key(strconv.Itoa(i))
+				w.write(child, childPath)
+			}
+			w.buf.WriteByte('}')
+
+		default:
+			w.write(group[0], childPath)
+		}
+	}
+
+	if text != "" {
+		next("#text")
+		w.value(text)
+	}
+
+	w.buf.WriteByte('}')
+}
+
+// key writes a json object key
+func (w *xmlWriter) key(k string) {
+	w.value(k)
+	w.buf.WriteByte(':')
+}
+
+// value writes a json string
+func (w *xmlWriter) value(v string) {
+	b, _ := json.Marshal(v)
+	w.buf.Write(b)
+}
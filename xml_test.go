@@ -0,0 +1,102 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScanXml(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/xml/working.xml",
+		MasterPath:  "test/xml/master.xml",
+	}
+
+	missing, err := ScanXml(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the working file's single appender isn't keyed by its index, which
+	// XmlLists avoids
+	sort.Strings(missing)
+	if expected := []string{"configuration.appender.0", "configuration.appender.1"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	different := []string{}
+	for _, d := range result.Different {
+		different = append(different, d.Key)
+	}
+	sort.Strings(different)
+
+	if expected := []string{"configuration.appSettings.add.1.@value", "configuration.root.@level"}; !reflect.DeepEqual(different, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, different)
+	}
+}
+
+func TestScanXmlLists(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/xml/working.xml",
+		MasterPath:  "test/xml/master.xml",
+		XmlLists:    []string{"configuration.appender"},
+		ArrayModes:  map[string]ArrayMode{"configuration.appender": ArraySet},
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	different := []string{}
+	for _, d := range result.Different {
+		different = append(different, d.Key)
+	}
+	sort.Strings(different)
+
+	if expected := []string{"configuration.appSettings.add.1.@value", "configuration.appender", "configuration.root.@level"}; !reflect.DeepEqual(different, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, different)
+	}
+}
+
+func TestXmlToJson(t *testing.T) {
+	tests := []struct {
+		xml      string
+		lists    []string
+		expected string
+	}{
+		{"", nil, `{}`},
+		{"<a><b>1</b><c x=\"2\">3</c><d/></a>", nil, `{"a":{"b":"1","c":{"@x":"2","#text":"3"},"d":""}}`},
+		{"<a><b>1</b><c/><b>2</b></a>", nil, `{"a":{"b":{"0":"1","1":"2"},"c":""}}`},
+		{"<a><b>1</b><b>2</b></a>", []string{"a.b"}, `{"a":{"b":["1","2"]}}`},
+		{"<a><b><c>1</c></b></a>", []string{"a.b"}, `{"a":{"b":[{"c":"1"}]}}`},
+		{"<?xml version=\"1.0\"?>\n<!-- c --><a><![CDATA[<x>]]></a>", nil, `{"a":"\u003cx\u003e"}`},
+		{"<a><b></a>", nil, ""},
+		{"<a/><b/>", nil, ""},
+		{"text", nil, ""},
+	}
+
+	for _, tt := range tests {
+		b, err := xmlToJson([]byte(tt.xml), tt.lists)
+
+		if tt.expected == "" {
+			if err == nil {
+				t.Fatalf("xml=%q expected an error actual=%s", tt.xml, b)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("xml=%q %s", tt.xml, err)
+		}
+
+		if string(b) != tt.expected {
+			t.Fatalf("xml=%q expected=%s actual=%s", tt.xml, tt.expected, b)
+		}
+	}
+}
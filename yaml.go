@@ -0,0 +1,273 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlMaxNodes limits the nodes a yaml document may expand to once its
+// aliases are followed, so a small file of nested aliases can't expand
+// exponentially
+const yamlMaxNodes = 1000000
+
+// ScanYaml will scan two .yaml configuration files returning a slice of keys
+// that exist in the master file and are missing in the working file. yaml
+// files are compared as json would be, with aliases and merge keys resolved
+func ScanYaml(c Config) ([]string, error) {
+	c.Format = FormatYaml
+	return ScanJson(c)
+}
+
+// ScanYamlResult will scan two .yaml configuration files returning a Result
+// holding every missing, extra and different key
+func ScanYamlResult(c Config) (*Result, error) {
+	c.Format = FormatYaml
+	return Scan(c)
+}
+
+// PrintYaml uses ScanYaml to retrieve a slice of missing keys and will then
+// print out the difference / discrepencies between the master and working files
+func PrintYaml(c Config) error {
+	c.Format = FormatYaml
+	return PrintJson(c)
+}
+
+// yamlToJson converts a yaml document to json, keeping the order of its keys
+// so that it can be compared like any json file. aliases and merge keys are
+// resolved, and the top level must be a mapping
+func yamlToJson(b []byte) ([]byte, error) {
+	return convertYaml(b, false)
+}
+
+// yamlIncludesToJson converts a yaml document to json like yamlToJson,
+// writing each "!include path" as a json reference, {"$ref": "path"}, for
+// Config.ResolveRefs to inline
+func yamlIncludesToJson(b []byte) ([]byte, error) {
+	return convertYaml(b, true)
+}
+
+// convertYaml converts a yaml document to json, writing includes as json
+// references if includes is set
+func convertYaml(b []byte, includes bool) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	// an empty document has no keys
+	if len(doc.Content) == 0 {
+		return []byte("{}"), nil
+	}
+
+	if root := yamlAlias(doc.Content[0]); root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: the top level must be a mapping", root.Line)
+	}
+
+	w := yamlWriter{includes: includes}
+	if err := w.write(doc.Content[0]); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// yamlWriter writes yaml nodes as json, counting the nodes written. merging
+// holds the mappings whose keys are being merged, so that a mapping merging
+// itself is caught, and merged the keys of each mapping once merged, so that
+// a mapping merged many times over is only walked once
+type yamlWriter struct {
+	buf      bytes.Buffer
+	nodes    int
+	includes bool
+	merging  map[*yaml.Node]bool
+	merged   map[*yaml.Node][]yamlPair
+}
+
+// yamlPair is a key of a yaml mapping and its value
+type yamlPair struct {
+	key   string
+	value *yaml.Node
+}
+
+// write writes a node, and each node within it, as json
+func (w *yamlWriter) write(n *yaml.Node) error {
+	if w.nodes++; w.nodes > yamlMaxNodes {
+		return errors.New("document expands to too many nodes")
+	}
+
+	n = yamlAlias(n)
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		pairs, err := w.pairs(n)
+		if err != nil {
+			return err
+		}
+
+		w.buf.WriteByte('{')
+		for i, p := range pairs {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+
+			key, _ := json.Marshal(p.key)
+			w.buf.Write(key)
+			w.buf.WriteByte(':')
+
+			if err := w.write(p.value); err != nil {
+				return err
+			}
+		}
+		w.buf.WriteByte('}')
+
+	case yaml.SequenceNode:
+		w.buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+
+			if err := w.write(item); err != nil {
+				return err
+			}
+		}
+		w.buf.WriteByte(']')
+
+	case yaml.ScalarNode:
+		if w.includes && n.Tag == "!include" {
+			ref, _ := json.Marshal(n.Value)
+			fmt.Fprintf(&w.buf, `{"$ref":%s}`, ref)
+			return nil
+		}
+
+		return w.scalar(n)
+
+	default:
+		return fmt.Errorf("line %d: unsupported yaml node", n.Line)
+	}
+
+	return nil
+}
+
+// scalar writes a scalar node as a json string, number, bool or null.
+// numbers are written as written in the file where json allows it, so they
+// are reported as written
+func (w *yamlWriter) scalar(n *yaml.Node) error {
+	switch n.ShortTag() {
+	case "!!null":
+		w.buf.WriteString("null")
+		return nil
+
+	case "!!bool", "!!int", "!!float":
+		if json.Valid([]byte(n.Value)) {
+			w.buf.WriteString(n.Value)
+			return nil
+		}
+
+		// e.g. 0x1f, 1_000 or True
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+
+		// .inf and .nan have no json equivalent so are kept as text
+		if b, err := json.Marshal(v); err == nil {
+			w.buf.Write(b)
+			return nil
+		}
+	}
+
+	b, _ := json.Marshal(n.Value)
+	w.buf.Write(b)
+
+	return nil
+}
+
+// pairs returns the keys of a mapping node in order, with the keys of any
+// mappings merged into it by "<<" following the keys it defines itself in
+// the position of the merge. keys defined by the mapping override merged
+// ones, and of several merged mappings the first to define a key wins
+func (w *yamlWriter) pairs(n *yaml.Node) ([]yamlPair, error) {
+	if w.merging[n] {
+		return nil, fmt.Errorf("line %d: a mapping can't be merged into itself", n.Line)
+	}
+
+	if pairs, ok := w.merged[n]; ok {
+		return pairs, nil
+	}
+
+	if w.merging == nil {
+		w.merging, w.merged = map[*yaml.Node]bool{}, map[*yaml.Node][]yamlPair{}
+	}
+
+	w.merging[n] = true
+	defer delete(w.merging, n)
+
+	own := map[string]bool{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := yamlAlias(n.Content[i]); k.ShortTag() != "!!merge" {
+			own[k.Value] = true
+		}
+	}
+
+	pairs, seen := []yamlPair{}, map[string]bool{}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := yamlAlias(n.Content[i]), n.Content[i+1]
+
+		if k.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: keys must be scalars", k.Line)
+		}
+
+		if k.ShortTag() != "!!merge" {
+			if seen[k.Value] {
+				return nil, fmt.Errorf("line %d: duplicate key %s", k.Line, k.Value)
+			}
+
+			seen[k.Value] = true
+			pairs = append(pairs, yamlPair{key: k.Value, value: v})
+			continue
+		}
+
+		merged := []*yaml.Node{yamlAlias(v)}
+		if merged[0].Kind == yaml.SequenceNode {
+			merged = merged[0].Content
+		}
+
+		for _, m := range merged {
+			if m = yamlAlias(m); m.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: a merge key must reference a mapping", m.Line)
+			}
+
+			mergedPairs, err := w.pairs(m)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range mergedPairs {
+				if !own[p.key] && !seen[p.key] {
+					seen[p.key] = true
+					pairs = append(pairs, p)
+				}
+			}
+		}
+	}
+
+	w.merged[n] = pairs
+
+	return pairs, nil
+}
+
+// yamlAlias returns the node an alias refers to, or the node itself if it
+// isn't an alias
+func yamlAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	return n
+}
@@ -0,0 +1,126 @@
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestScanYaml(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/yaml/working.yml",
+		MasterPath:  "test/yaml/master.yaml",
+	}
+
+	missing, err := ScanYaml(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// worker lacks the timeout and queue its merged defaults would give it
+	sort.Strings(missing)
+	if expected := []string{"database.replica", "services.worker.queue", "services.worker.timeout"}; !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, missing)
+	}
+
+	result, err := Scan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	different := []string{}
+	for _, d := range result.Different {
+		different = append(different, d.String())
+	}
+
+	// "no" is a string in yaml 1.2, and 1.50 is the same number as 1.5
+	expected := []string{"database.host=db.internal", "debug=no", "services.api.timeout=10"}
+	if !reflect.DeepEqual(different, expected) {
+		t.Fatalf("expected=%v actual=%v", expected, different)
+	}
+}
+
+func TestScanYamlAnchors(t *testing.T) {
+	c := Config{
+		WorkingPath: "test/yaml/anchors/working.yaml",
+		MasterPath:  "test/yaml/anchors/master.yaml",
+		JSONOrdered: true,
+	}
+
+	// the working file inlines every anchor, alias and merge of the master
+	result, err := ScanYamlResult(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Missing) != 0 || len(result.Extra) != 0 || len(result.Different) != 0 || len(result.OrderChanged) != 0 {
+		t.Fatalf("expected the files to compare equal actual=%s", result.Diff())
+	}
+}
+
+func TestYamlToJson(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		expected string
+	}{
+		{"", `{}`},
+		{"b: 1\na: two\n", `{"b":1,"a":"two"}`},
+		{"a: [1, 0x1f, 1_000, .inf, ~, true, 2020-01-01, '3']", `{"a":[1,31,1000,".inf",null,true,"2020-01-01","3"]}`},
+		{"a: &x {b: 1, c: 2}\nd: *x", `{"a":{"b":1,"c":2},"d":{"b":1,"c":2}}`},
+		{"a: &x {b: 1, c: 2}\nd:\n  <<: *x\n  c: 3", `{"a":{"b":1,"c":2},"d":{"b":1,"c":3}}`},
+		{"a: &x {b: 1}\ne: &y {b: 2, c: 2}\nd:\n  <<: [*x, *y]", `{"a":{"b":1},"e":{"b":2,"c":2},"d":{"b":1,"c":2}}`},
+		{"- a\n- b", ""},
+		{"a: 1\na: 2", ""},
+		{"a: 1\nb:\n  <<: 2", ""},
+		{"a: [", ""},
+	}
+
+	for _, tt := range tests {
+		b, err := yamlToJson([]byte(tt.yaml))
+
+		if tt.expected == "" {
+			if err == nil {
+				t.Fatalf("yaml=%q expected an error actual=%s", tt.yaml, b)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("yaml=%q %s", tt.yaml, err)
+		}
+
+		if string(b) != tt.expected {
+			t.Fatalf("yaml=%q expected=%s actual=%s", tt.yaml, tt.expected, b)
+		}
+	}
+}
+
+func TestYamlToJsonAliasExpansion(t *testing.T) {
+	// each level doubles the nodes of the last, 2^30 in all
+	doc := "a0: &a0 [x, x]\n"
+	for i := 1; i <= 30; i++ {
+		doc += fmt.Sprintf("a%d: &a%d [*a%d, *a%d]\n", i, i, i-1, i-1)
+	}
+
+	if _, err := yamlToJson([]byte(doc)); err == nil || !strings.Contains(err.Error(), "too many nodes") {
+		t.Fatalf("expected the expansion to be limited actual=%v", err)
+	}
+
+	// merging the last mapping twice at each level is walked once per level,
+	// rather than 2^30 times
+	doc = "a0: &a0 {x: 1}\n"
+	for i := 1; i <= 30; i++ {
+		doc += fmt.Sprintf("a%d: &a%d {<<: [*a%d, *a%d]}\n", i, i, i-1, i-1)
+	}
+
+	b, err := yamlToJson([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(string(b), `"a30":{"x":1}}`) {
+		t.Fatalf("unexpected json %s", b)
+	}
+}